@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// renderAnimation renders frames images, the scene and camera of each frame being
+// given by frameScene, and hands each image with its statistics to save along with
+// its number, counted from 1.
+func renderAnimation(frames int, frameScene func(frame int) (Scene, Camera), width, height int, opts RenderOptions, save func(frame int, image Image, stats RenderStats) error) error {
+	for frame := 0; frame < frames; frame++ {
+		scene, camera := frameScene(frame)
+		image, stats, err := scene.Render(camera, width, height, opts)
+		if err != nil {
+			return fmt.Errorf("frame %d: %v", frame+1, err)
+		}
+		if err := save(frame+1, image, stats); err != nil {
+			return fmt.Errorf("frame %d: %v", frame+1, err)
+		}
+	}
+	return nil
+}
+
+// framePath returns path with the number of the frame inserted before its
+// extension, such as depth_0001.png for depth.png.
+func framePath(path string, frame int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s_%04d%s", strings.TrimSuffix(path, ext), frame, ext)
+}
+
+// turntable returns the camera orbiting around the vertical axis going through
+// its target, rotated by angle radians from camera.
+func turntable(camera Camera, angle float64) Camera {
+	sin, cos := float32(math.Sin(angle)), float32(math.Cos(angle))
+	rotate := func(v Vec3f) Vec3f {
+		return Vec3f{v.x*cos + v.z*sin, v.y, -v.x*sin + v.z*cos}
+	}
+
+	camera.position = Add(camera.at, rotate(Sub(camera.position, camera.at)))
+	// La base précalculée et le vecteur up tournent avec la caméra
+	camera.up = rotate(camera.up)
+	camera.right = rotate(camera.right)
+	camera.vertical = rotate(camera.vertical)
+	camera.forward = rotate(camera.forward)
+	return camera
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderAnimationTurntable(t *testing.T) {
+	scene, _ := sphereScene()
+	camera, _ := NewCamera(Vec3f{}, Vec3f{0, 0, 5}, Vec3f{0, 1, 0}, 40)
+	// La caméra tourne autour de la sphère rouge ; la sphère verte décentrée change de
+	// place à l'écran et la lumière au-dessus éclaire la scène de tous les points de vue
+	scene.addElement(Sphere{0.5, Vec3f{1.5, 0, 5}, Lambert{Vec3f{0, 1, 0}}})
+	scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{0, 10, 5}})
+	frameScene := func(frame int) (Scene, Camera) {
+		return scene, turntable(camera, 2*math.Pi*float64(frame)/3)
+	}
+	dir := t.TempDir()
+	save := func(frame int, image Image, _ RenderStats) error {
+		return image.save(filepath.Join(dir, fmt.Sprintf("frame_%04d.png", frame)))
+	}
+	if err := renderAnimation(3, frameScene, 16, 16, RenderOptions{}, save); err != nil {
+		t.Fatal(err)
+	}
+
+	var frames [][]byte
+	for i := 1; i <= 3; i++ {
+		data, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("frame_%04d.png", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		frames = append(frames, data)
+	}
+	for i := range frames {
+		for j := i + 1; j < len(frames); j++ {
+			if bytes.Equal(frames[i], frames[j]) {
+				t.Errorf("frames %d and %d are identical", i+1, j+1)
+			}
+		}
+	}
+}
+
+func TestTurntableRotatesUp(t *testing.T) {
+	// Caméra penchée : son vecteur up n'est pas vertical
+	up := Vec3f{1, 1, 0}.normalized()
+	camera, err := NewCamera(Vec3f{}, Vec3f{0, 0, 5}, up, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	turned := turntable(camera, math.Pi/2)
+	want, err := NewCamera(turned.position, turned.at, turned.up, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !approxVec(turned.up, Vec3f{0, 1, -1}.normalized(), 1e-6) {
+		t.Errorf("up = %v, want (0, 0.707, -0.707)", turned.up)
+	}
+	// La base recalculée depuis up doit être celle qui a tourné avec la caméra
+	right, vertical, forward := turned.basis()
+	wantRight, wantVertical, wantForward := want.basis()
+	if !approxVec(right, wantRight, 1e-5) || !approxVec(vertical, wantVertical, 1e-5) || !approxVec(forward, wantForward, 1e-5) {
+		t.Errorf("basis = %v, %v, %v, want %v, %v, %v", right, vertical, forward, wantRight, wantVertical, wantForward)
+	}
+	turned.right, turned.vertical, turned.forward = Vec3f{}, Vec3f{}, Vec3f{}
+	right, vertical, forward = turned.basis()
+	if !approxVec(right, wantRight, 1e-5) || !approxVec(vertical, wantVertical, 1e-5) || !approxVec(forward, wantForward, 1e-5) {
+		t.Errorf("basis from up = %v, %v, %v, want %v, %v, %v", right, vertical, forward, wantRight, wantVertical, wantForward)
+	}
+}
+
+func TestFramePath(t *testing.T) {
+	for _, tt := range []struct {
+		path string
+		want string
+	}{
+		{"depth.png", "depth_0003.png"},
+		{filepath.Join("out", "frame.jpg"), filepath.Join("out", "frame_0003.jpg")},
+		{"depth", "depth_0003"},
+	} {
+		if got := framePath(tt.path, 3); got != tt.want {
+			t.Errorf("framePath(%q, 3) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
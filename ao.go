@@ -0,0 +1,39 @@
+package main
+
+// defaultAORadius is the distance within which geometry occludes a point when
+// ambient occlusion is enabled with setAmbientOcclusion.
+const defaultAORadius = 1
+
+// setAmbientOcclusion enables ambient occlusion, casting samples rays in the
+// hemisphere of each shaded point and looking for geometry closer than radius.
+// A sample count of 0 disables it.
+func (s *Scene) setAmbientOcclusion(samples int, radius float32) {
+	s.aoSamples = samples
+	s.aoRadius = radius
+}
+
+// ambientOcclusion returns the fraction of the hemisphere around the normal n at
+// the point p that is not occluded by nearby geometry, between 0 (fully occluded)
+// and 1 (fully exposed). Directions are weighted by the cosine of their angle to
+// the normal, as the light they bring. It returns 1 when ambient occlusion is disabled.
+// The rays are taken from the budget of ctx: when it is exhausted, fewer are cast, and
+// the point is left unoccluded if none can be.
+func (s Scene) ambientOcclusion(p, n Vec3f, ctx rayContext) float32 {
+	if s.aoSamples <= 0 {
+		return 1
+	}
+	samples := ctx.spend(s.aoSamples)
+	if samples == 0 {
+		return 1
+	}
+
+	from := ctx.offset(p, n)
+	open := 0
+	for i := 0; i < samples; i++ {
+		dir := cosineSampleHemisphere(n, ctx.rng)
+		if !s.isBlocked(from, dir, s.aoRadius) {
+			open++
+		}
+	}
+	return float32(open) / float32(samples)
+}
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestAmbientOcclusionCrevice(t *testing.T) {
+	scene := Scene{}
+	scene.addElement(Plane{point: Vec3f{}, normal: Vec3f{0, 1, 0}, Material: Lambert{Vec3f{1, 1, 1}}})
+	scene.addElement(Sphere{1, Vec3f{-1.05, 1, 0}, Lambert{Vec3f{1, 1, 1}}})
+	scene.addElement(Sphere{1, Vec3f{1.05, 1, 0}, Lambert{Vec3f{1, 1, 1}}})
+	scene.setAmbientOcclusion(256, 2)
+
+	up := Vec3f{0, 1, 0}
+	crevice := scene.ambientOcclusion(Vec3f{}, up, testContext())
+	exposed := scene.ambientOcclusion(Vec3f{10, 0, 0}, up, testContext())
+	if exposed != 1 {
+		t.Errorf("AO of an exposed point = %v, want 1", exposed)
+	}
+	if crevice >= exposed {
+		t.Errorf("AO in the crevice = %v, want less than %v at the exposed point", crevice, exposed)
+	}
+}
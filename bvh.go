@@ -0,0 +1,104 @@
+package main
+
+import "sort"
+
+// bvhLeafSize is the maximum number of objects stored in a leaf of the BVH.
+const bvhLeafSize = 4
+
+// BVHNode is a node of a bounding volume hierarchy. Inner nodes have two children
+// whose boxes are contained in box; leaves directly hold their objects.
+type BVHNode struct {
+	box         AABB
+	left, right *BVHNode
+	objects     []indexedObject
+	// Objets non bornés (plans...), testés à chaque rayon depuis la racine
+	unbounded []indexedObject
+}
+
+// Build builds a bounding volume hierarchy over objects, the identifier of each object
+// being its index and its tie-break key keys[id]; nil entries (removed objects) are
+// skipped. Unbounded objects cannot
+// be sorted into boxes, so they are kept aside in the root and always tested.
+func Build(objects []GeometricObject, keys []uint64) *BVHNode {
+	var bounded, unbounded []indexedObject
+	for id, object := range objects {
+		if object == nil {
+			continue
+		}
+		if object.bounds().isFinite() {
+			bounded = append(bounded, indexedObject{object, id, keys[id]})
+		} else {
+			unbounded = append(unbounded, indexedObject{object, id, keys[id]})
+		}
+	}
+
+	root := buildNode(bounded)
+	root.unbounded = unbounded
+	return root
+}
+
+// buildNode recursively splits objects in two halves along the longest axis of
+// the box containing their centroids.
+func buildNode(objects []indexedObject) *BVHNode {
+	node := &BVHNode{}
+	if len(objects) == 0 {
+		return node
+	}
+
+	node.box = objects[0].object.bounds()
+	centroids := AABB{min: node.box.centroid(), max: node.box.centroid()}
+	for _, o := range objects[1:] {
+		b := o.object.bounds()
+		node.box = union(node.box, b)
+		centroids = union(centroids, AABB{min: b.centroid(), max: b.centroid()})
+	}
+
+	if len(objects) <= bvhLeafSize {
+		node.objects = objects
+		return node
+	}
+
+	// Axe le plus long de la boîte des centres
+	_, axis := Sub(centroids.max, centroids.min).maxComponent()
+
+	sorted := make([]indexedObject, len(objects))
+	copy(sorted, objects)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].object.bounds().centroid().At(axis) < sorted[j].object.bounds().centroid().At(axis)
+	})
+
+	mid := len(sorted) / 2
+	node.left = buildNode(sorted[:mid])
+	node.right = buildNode(sorted[mid:])
+	return node
+}
+
+// traverse returns the nearest hit of the ray among the objects of the hierarchy.
+// The number of intersection tests made is added to tests.
+func (n *BVHNode) traverse(ro, rd Vec3f, tests *int) nearestHit {
+	nearest := newNearestHit()
+	*tests += len(n.unbounded)
+	for _, o := range n.unbounded {
+		nearest.test(o, ro, rd)
+	}
+	n.traverseNode(ro, rd, &nearest, tests)
+	return nearest
+}
+
+// traverseNode updates nearest with the objects of the subtree, skipping the subtrees
+// whose box is missed or farther than the nearest hit.
+func (n *BVHNode) traverseNode(ro, rd Vec3f, nearest *nearestHit, tests *int) {
+	if n == nil || (n.left == nil && n.right == nil && len(n.objects) == 0) {
+		return
+	}
+	if ok, tEnter, tExit := n.box.slabs(ro, rd); !ok || tExit < 0 || tEnter > nearest.t {
+		return
+	}
+
+	*tests += len(n.objects)
+	for _, o := range n.objects {
+		nearest.test(o, ro, rd)
+	}
+	n.left.traverseNode(ro, rd, nearest, tests)
+	n.right.traverseNode(ro, rd, nearest, tests)
+}
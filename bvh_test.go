@@ -0,0 +1,80 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// randomSpheres returns a scene of n small spheres spread at random in a cube.
+func randomSpheres(n int, seed int64) Scene {
+	rng := rand.New(rand.NewSource(seed))
+	scene := Scene{}
+	for i := 0; i < n; i++ {
+		center := Vec3f{rng.Float32()*20 - 10, rng.Float32()*20 - 10, rng.Float32()*20 - 10}
+		scene.addElement(Sphere{0.1 + rng.Float32()*0.3, center, Lambert{Vec3f{1, 1, 1}}})
+	}
+	return scene
+}
+
+// randomRays returns n rays starting outside of the cube of randomSpheres and
+// aimed at random points inside it.
+func randomRays(n int, seed int64) [][2]Vec3f {
+	rng := rand.New(rand.NewSource(seed))
+	rays := make([][2]Vec3f, n)
+	for i := range rays {
+		ro := randomUnitVector(rng).mul(30)
+		target := Vec3f{rng.Float32()*20 - 10, rng.Float32()*20 - 10, rng.Float32()*20 - 10}
+		rays[i] = [2]Vec3f{ro, Sub(target, ro).normalized()}
+	}
+	return rays
+}
+
+func BenchmarkNearest(b *testing.B) {
+	scene := randomSpheres(3000, 1)
+	rays := randomRays(1024, 2)
+	bvh := scene
+	bvh.buildBVH()
+	for _, bench := range []struct {
+		name  string
+		scene Scene
+	}{
+		{"linear", scene},
+		{"bvh", bvh},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ray := rays[i%len(rays)]
+				bench.scene.nearest(ray[0], ray[1])
+			}
+		})
+	}
+}
+
+func BenchmarkCoincidentHits(b *testing.B) {
+	// Sphères confondues de couleurs différentes : chaque rayon départage toutes les égalités
+	scene := Scene{}
+	for i := 0; i < 64; i++ {
+		scene.addElement(Sphere{1, Vec3f{0, 0, 5}, Lambert{Vec3f{float32(i) / 64, 0, 0}}})
+	}
+	// Rayons visant le centre des sphères depuis des points au hasard
+	rays := randomRays(1024, 2)
+	for i, ray := range rays {
+		rays[i][1] = Sub(Vec3f{0, 0, 5}, ray[0]).normalized()
+	}
+	bvh := scene
+	bvh.buildBVH()
+	for _, bench := range []struct {
+		name  string
+		scene Scene
+	}{
+		{"linear", scene},
+		{"bvh", bvh},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ray := rays[i%len(rays)]
+				bench.scene.nearest(ray[0], ray[1])
+			}
+		})
+	}
+}
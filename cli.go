@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const usage = `usage: go_tp3 <command> [flags]
+
+commands:
+  render    render a scene to an image (the default when the first argument is a flag)
+  info      print the number of objects and lights of a scene and its bounds
+  validate  check that a scene file can be rendered
+
+Run go_tp3 <command> -h for the flags of a command.
+`
+
+// errUsage marks the errors of a command line that could not be parsed; the flag
+// package has already printed them along with the usage.
+var errUsage = errors.New("invalid command line")
+
+// run runs the subcommand named by args[0] with the rest of args and returns the exit
+// status of the program: 0 on success, 1 if the command failed and 2 if the command
+// line is invalid. When args does not start with a subcommand, render is run, so that
+// the flags of the former single command keep working.
+func run(args []string, stdout, stderr io.Writer) int {
+	name := "render"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	var err error
+	switch name {
+	case "render":
+		err = renderCommand(args, stderr)
+	case "info":
+		err = infoCommand(args, stdout, stderr)
+	case "validate":
+		err = validateCommand(args, stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", name, usage)
+		return 2
+	}
+
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	}
+	fmt.Fprintf(stderr, "%s: %v\n", name, err)
+	return 1
+}
+
+// parseFlags parses args with fs, returning flag.ErrHelp if the help was asked and
+// errUsage if args are invalid.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", errUsage, err)
+}
+
+// infoCommand runs the info subcommand, printing the number of objects and lights of
+// the built-in scene or of a scene file and the box containing its bounded objects.
+func infoCommand(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("info", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var sceneFile = fs.String("scene", "", "JSON scene file to describe instead of the built-in scene")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	scene := Scene{}
+	if *sceneFile != "" {
+		var err error
+		if scene, _, err = LoadScene(*sceneFile); err != nil {
+			return err
+		}
+	} else {
+		populateScene(&scene)
+	}
+
+	objects := scene.elements()
+	bounded := 0
+	for _, object := range objects {
+		if object.bounds().isFinite() {
+			bounded++
+		}
+	}
+	fmt.Fprintf(stdout, "objects: %d (%d unbounded)\n", len(objects), len(objects)-bounded)
+	fmt.Fprintf(stdout, "lights: %d\n", len(scene.lights))
+	if bounded > 0 {
+		b := scene.bounds()
+		fmt.Fprintf(stdout, "bounds: (%g, %g, %g) to (%g, %g, %g)\n", b.min.x, b.min.y, b.min.z, b.max.x, b.max.y, b.max.z)
+	}
+	return nil
+}
+
+// validateCommand runs the validate subcommand, checking that a scene file can be
+// loaded and that its scene and camera are valid.
+func validateCommand(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var sceneFile = fs.String("scene", "", "JSON scene file to check")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *sceneFile == "" {
+		fmt.Fprintln(stderr, "validate: missing -scene")
+		fs.Usage()
+		return errUsage
+	}
+
+	scene, camera, err := LoadScene(*sceneFile)
+	if err != nil {
+		return err
+	}
+	if err := scene.Validate(); err != nil {
+		return fmt.Errorf("%s: invalid scene: %v", *sceneFile, err)
+	}
+	if err := camera.Validate(); err != nil {
+		return fmt.Errorf("%s: invalid camera: %v", *sceneFile, err)
+	}
+	fmt.Fprintf(stdout, "%s: valid\n", *sceneFile)
+	return nil
+}
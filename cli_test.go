@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateCommand(t *testing.T) {
+	const camera = `"camera": {"position": [0, 0, -5], "at": [0, 0, 0], "up": [0, 1, 0], "fov": 45}`
+	for _, tt := range []struct {
+		name, scene string
+		status      int
+		message     string
+	}{
+		{"valid", `{` + camera + `, "spheres": [{"radius": 1, "position": [0, 0, 0], "material": {"type": "lambert", "kd": [1, 0, 0]}}],
+			"lights": [{"position": [0, 10, 0], "color": [1, 1, 1]}]}`, 0, "valid"},
+		{"negative radius", `{` + camera + `, "spheres": [{"radius": -1, "position": [0, 0, 0], "material": {"type": "lambert", "kd": [1, 0, 0]}}],
+			"lights": [{"position": [0, 10, 0], "color": [1, 1, 1]}]}`, 1, "sphere radius -1 is not positive"},
+		{"no light", `{` + camera + `, "spheres": [{"radius": 1, "position": [0, 0, 0], "material": {"type": "lambert", "kd": [1, 0, 0]}}]}`, 1, "no light"},
+		{"unknown material", `{` + camera + `, "spheres": [{"radius": 1, "position": [0, 0, 0], "material": {"type": "plasma"}}]}`, 1, `unknown material type "plasma"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "scene.json")
+			if err := os.WriteFile(path, []byte(tt.scene), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			var stdout, stderr bytes.Buffer
+			status := run([]string{"validate", "-scene", path}, &stdout, &stderr)
+			if status != tt.status {
+				t.Errorf("exit status = %d, want %d (stderr: %q)", status, tt.status, stderr.String())
+			}
+			output := stdout.String() + stderr.String()
+			if !strings.Contains(output, tt.message) {
+				t.Errorf("output = %q, want it to mention %q", output, tt.message)
+			}
+		})
+	}
+}
+
+func TestValidateCommandMissingScene(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"validate"}, &stdout, &stderr); status != 2 {
+		t.Errorf("exit status = %d, want 2 for a missing -scene", status)
+	}
+}
+
+func TestRenderCommandFrames(t *testing.T) {
+	scene := `{"camera": {"position": [0, 0, -5], "at": [0, 0, 0], "up": [0, 1, 0], "fov": 45},
+		"spheres": [{"radius": 1, "position": [0, 0, 0], "material": {"type": "lambert", "kd": [1, 0, 0]}}],
+		"lights": [{"position": [0, 10, 0], "color": [1, 1, 1]}]}`
+	dir := t.TempDir()
+	path := filepath.Join(dir, "scene.json")
+	if err := os.WriteFile(path, []byte(scene), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	frames := filepath.Join(dir, "frames")
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"render", "-scene", path, "-width", "8", "-height", "6", "-supersample", "2",
+		"-frames", "2", "-out-dir", frames, "-out", "result.jpg", "-depth-out", filepath.Join(dir, "depth.png"), "-stats"}, &stdout, &stderr)
+	if status != 0 {
+		t.Fatalf("exit status = %d, want 0 (stderr: %q)", status, stderr.String())
+	}
+	// Chaque image est réduite au format de -out, avec sa profondeur et ses statistiques
+	for _, name := range []string{filepath.Join(frames, "frame_0001.jpg"), filepath.Join(frames, "frame_0002.jpg"),
+		filepath.Join(dir, "depth_0001.png"), filepath.Join(dir, "depth_0002.png")} {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config, format, err := image.DecodeConfig(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if want := strings.TrimPrefix(filepath.Ext(name), "."); format != want && !(want == "jpg" && format == "jpeg") {
+			t.Errorf("%s: format = %s, want %s", name, format, want)
+		}
+		if config.Width != 8 || config.Height != 6 {
+			t.Errorf("%s: size = %dx%d, want 8x6", name, config.Width, config.Height)
+		}
+	}
+	if n := strings.Count(stderr.String(), "rendered in"); n != 2 {
+		t.Errorf("stderr = %q, want the statistics of both frames", stderr.String())
+	}
+}
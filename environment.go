@@ -0,0 +1,55 @@
+package main
+
+// Environment is an equirectangular image surrounding the scene, seen by the rays
+// hitting no object: its columns span the longitudes and its rows the latitudes,
+// from straight up at the top to straight down at the bottom.
+type Environment struct {
+	texture Texture
+}
+
+// LoadEnvironment decodes the equirectangular PNG or JPEG image at path into an Environment.
+func LoadEnvironment(path string) (Environment, error) {
+	tex, err := LoadTexture(path)
+	if err != nil {
+		return Environment{}, err
+	}
+	tex.bilinear = true
+	return Environment{tex}, nil
+}
+
+// sample returns the color of the environment in the direction dir, using the
+// same longitude/latitude mapping as the textures of spheres.
+func (e Environment) sample(dir Vec3f) Vec3f {
+	u, v := sphericalUV(dir.normalized())
+	if e.texture.bilinear {
+		return e.texture.sampleBilinear(u, v)
+	}
+	return e.texture.sample(u, v)
+}
+
+// setEnvironment makes the rays hitting no object sample env instead of the
+// background colors.
+func (s *Scene) setEnvironment(env Environment) {
+	s.environment = &env
+}
+
+// setEnvironmentLighting makes the ambient term of the materials sample the
+// environment in the direction of the normal instead of using the ambient light, an
+// approximation of the light it brings to the surface (image-based lighting).
+// Lambert surfaces, which have no ambient term otherwise, receive it too.
+func (s *Scene) setEnvironmentLighting(on bool) {
+	s.environmentLighting = on
+}
+
+// usesEnvironmentLighting reports whether the ambient light comes from the environment.
+func (s Scene) usesEnvironmentLighting() bool {
+	return s.environmentLighting && s.environment != nil
+}
+
+// ambientAt returns the ambient light received by a surface of normal n.
+func (s Scene) ambientAt(n Vec3f) Vec3f {
+	if s.usesEnvironmentLighting() {
+		return s.environment.sample(n)
+	}
+	return s.ambiantLight
+}
@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestEnvironmentOppositeColumns(t *testing.T) {
+	// Une couleur par colonne, identique sur les deux lignes
+	columns := []Vec3f{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}, {1, 1, 0}}
+	tex := Texture{width: 4, height: 2}
+	for y := 0; y < 2; y++ {
+		tex.texels = append(tex.texels, columns...)
+	}
+	scene := Scene{}
+	scene.setEnvironment(Environment{tex})
+
+	// +x est au milieu de l'image, -x sur son bord, à une demi-largeur de distance
+	if got := scene.backgroundColor(Vec3f{1, 0, 0}); got != columns[2] {
+		t.Errorf("environment towards +x = %v, want the column 2 %v", got, columns[2])
+	}
+	if got := scene.backgroundColor(Vec3f{-1, 0, 0}); got != columns[0] {
+		t.Errorf("environment towards -x = %v, want the column 0 %v", got, columns[0])
+	}
+}
+
+func TestEnvironmentLightingFollowsNormal(t *testing.T) {
+	// Ciel blanc au-dessus de l'horizon, sol noir en dessous
+	white, black := Vec3f{1, 1, 1}, Vec3f{}
+	tex := Texture{texels: []Vec3f{white, white, black, black}, width: 2, height: 2}
+	ambient := func(normal Vec3f) Vec3f {
+		scene := Scene{}
+		scene.setEnvironment(Environment{tex})
+		scene.setEnvironmentLighting(true)
+		scene.addElement(Plane{point: Vec3f{}, normal: normal, Material: Lambert{Vec3f{0.5, 0.5, 0.5}}})
+		return shade(t, scene, normal, normal.inverte())
+	}
+	up, down := ambient(Vec3f{0, 1, 0}), ambient(Vec3f{0, -1, 0})
+	if up.x <= down.x {
+		t.Errorf("surface facing the bright sky = %v, want brighter than %v facing the dark ground", up, down)
+	}
+	if want := (Vec3f{0.5, 0.5, 0.5}); !approxVec(up, want, 1e-6) {
+		t.Errorf("surface facing the sky = %v, want kd times white %v", up, want)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import "math"
+
+// gridDensity is the average number of cells per object of a Grid.
+const gridDensity = 3
+
+// gridMaxResolution is the maximum number of cells of a Grid along an axis.
+const gridMaxResolution = 64
+
+// Grid is a uniform grid of cells (voxels) over the bounds of the scene, each cell
+// listing the objects overlapping it. It is simpler to build than a BVH and can be
+// faster when the objects are evenly spread.
+type Grid struct {
+	box      AABB
+	res      [3]int
+	cellSize Vec3f
+	cells    [][]indexedObject
+	// Objets non bornés (plans...), testés à chaque rayon
+	unbounded []indexedObject
+}
+
+// BuildGrid builds a grid over objects, with about gridDensity cells per object, the
+// identifier of each object being its index and its tie-break key keys[id]; nil entries (removed objects) are
+// skipped. Unbounded objects cannot be put into cells, so they are kept aside and
+// always tested.
+func BuildGrid(objects []GeometricObject, keys []uint64) *Grid {
+	g := &Grid{}
+	var bounded []indexedObject
+	for id, object := range objects {
+		if object == nil {
+			continue
+		}
+		b := object.bounds()
+		if !b.isFinite() {
+			g.unbounded = append(g.unbounded, indexedObject{object, id, keys[id]})
+			continue
+		}
+		if len(bounded) == 0 {
+			g.box = b
+		} else {
+			g.box = union(g.box, b)
+		}
+		bounded = append(bounded, indexedObject{object, id, keys[id]})
+	}
+	if len(bounded) == 0 {
+		return g
+	}
+
+	// Une boîte plate (objets coplanaires) garde une épaisseur non nulle
+	size := Sub(g.box.max, g.box.min)
+	longest, _ := size.maxComponent()
+	for axis := 0; axis < 3; axis++ {
+		if size.At(axis) < longest*1e-3 || size.At(axis) == 0 {
+			pad := max(longest*1e-3, 1e-3)
+			g.box.min.Set(axis, g.box.min.At(axis)-pad/2)
+			g.box.max.Set(axis, g.box.max.At(axis)+pad/2)
+		}
+	}
+	size = Sub(g.box.max, g.box.min)
+
+	// Nombre de cellules par unité de longueur tel que la grille en compte
+	// environ gridDensity par objet
+	volume := float64(size.x) * float64(size.y) * float64(size.z)
+	perUnit := math.Cbrt(gridDensity * float64(len(bounded)) / volume)
+	for axis := 0; axis < 3; axis++ {
+		g.res[axis] = min(max(int(float64(size.At(axis))*perUnit), 1), gridMaxResolution)
+		g.cellSize.Set(axis, size.At(axis)/float32(g.res[axis]))
+	}
+
+	g.cells = make([][]indexedObject, g.res[0]*g.res[1]*g.res[2])
+	for _, o := range bounded {
+		b := o.object.bounds()
+		lo, hi := g.cellOf(b.min), g.cellOf(b.max)
+		for z := lo[2]; z <= hi[2]; z++ {
+			for y := lo[1]; y <= hi[1]; y++ {
+				for x := lo[0]; x <= hi[0]; x++ {
+					idx := g.index([3]int{x, y, z})
+					g.cells[idx] = append(g.cells[idx], o)
+				}
+			}
+		}
+	}
+	return g
+}
+
+// cellOf returns the coordinates of the cell containing the point p, clamped to the grid.
+func (g *Grid) cellOf(p Vec3f) [3]int {
+	var cell [3]int
+	for axis := 0; axis < 3; axis++ {
+		c := int((p.At(axis) - g.box.min.At(axis)) / g.cellSize.At(axis))
+		cell[axis] = min(max(c, 0), g.res[axis]-1)
+	}
+	return cell
+}
+
+func (g *Grid) index(cell [3]int) int {
+	return (cell[2]*g.res[1]+cell[1])*g.res[0] + cell[0]
+}
+
+// traverse returns the nearest hit of the ray among the objects of the grid. It walks
+// the cells pierced by the ray in order (3D-DDA, Amanatides & Woo) and stops at the
+// first cell whose exit is beyond the nearest hit. The number of intersection tests
+// made is added to tests.
+func (g *Grid) traverse(ro, rd Vec3f, tests *int) nearestHit {
+	nearest := newNearestHit()
+	test := func(objects []indexedObject) {
+		*tests += len(objects)
+		for _, o := range objects {
+			nearest.test(o, ro, rd)
+		}
+	}
+	test(g.unbounded)
+	if g.cells == nil {
+		return nearest
+	}
+
+	ok, tEnter, tExit := g.box.slabs(ro, rd)
+	if !ok || tExit < 0 || tEnter > nearest.t {
+		return nearest
+	}
+	tEnter = max(tEnter, 0)
+	cell := g.cellOf(Add(ro, rd.mul(tEnter)))
+
+	// Pour chaque axe : sens de parcours, distance à la prochaine frontière de cellule
+	// et distance entre deux frontières
+	var step [3]int
+	var tNext, tDelta [3]float32
+	for axis := 0; axis < 3; axis++ {
+		o, d, size := ro.At(axis), rd.At(axis), g.cellSize.At(axis)
+		lo := g.box.min.At(axis) + float32(cell[axis])*size
+		switch {
+		case d > 0:
+			step[axis] = 1
+			tNext[axis] = (lo + size - o) / d
+			tDelta[axis] = size / d
+		case d < 0:
+			step[axis] = -1
+			tNext[axis] = (lo - o) / d
+			tDelta[axis] = -size / d
+		default:
+			tNext[axis] = float32(math.Inf(1))
+		}
+	}
+
+	for {
+		test(g.cells[g.index(cell)])
+
+		// Axe de la frontière la plus proche, par laquelle le rayon sort de la cellule ;
+		// un objet de la cellule suivante touché à la même distance peut encore l'emporter
+		exit, axis := Vec3f{tNext[0], tNext[1], tNext[2]}.minComponent()
+		if nearest.t < exit {
+			return nearest
+		}
+		cell[axis] += step[axis]
+		if cell[axis] < 0 || cell[axis] >= g.res[axis] {
+			return nearest
+		}
+		tNext[axis] += tDelta[axis]
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestGridMatchesLinearScan(t *testing.T) {
+	linear := randomSpheres(500, 3)
+	// Un plan non borné, que la grille garde à part
+	linear.addElement(Plane{point: Vec3f{0, -12, 0}, normal: Vec3f{0, 1, 0}, Material: Lambert{Vec3f{1, 1, 1}}})
+	grid := linear
+	grid.buildGrid()
+
+	hits := 0
+	for i, ray := range randomRays(2000, 4) {
+		want := linear.nearestHit(ray[0], ray[1])
+		got := grid.nearestHit(ray[0], ray[1])
+		if got.id != want.id || got.t != want.t {
+			t.Fatalf("ray %d: grid hit %d at t = %v, want %d at t = %v as the linear scan", i, got.id, got.t, want.id, want.t)
+		}
+		if want.object != nil {
+			hits++
+		}
+	}
+	if hits == 0 {
+		t.Error("no ray hit anything, the test compares nothing")
+	}
+}
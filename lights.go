@@ -0,0 +1,124 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// LightSource is implemented by every kind of light the materials can be lit by.
+type LightSource interface {
+	// illuminate returns, for the point p, the unit vector pointing towards the light,
+	// the color of the light reaching p and the distance to the light (+Inf if the
+	// light is infinitely distant).
+	illuminate(p Vec3f) (L, color Vec3f, dist float32)
+}
+
+// ------------------
+// Light is a point light emitting in every direction from position.
+// Its color is scaled by intensity, which may exceed 1 for bright HDR lights (0 is
+// taken as 1), and at distance d by 1/(constant + linear*d + quadratic*d*d);
+// when the three coefficients are zero the light is not attenuated.
+type Light struct {
+	color     Vec3f
+	position  Vec3f
+	intensity float32
+
+	constant, linear, quadratic float32
+}
+
+// radiance returns the color of the light scaled by its intensity.
+func (l Light) radiance() Vec3f {
+	if l.intensity == 0 {
+		return l.color
+	}
+	return l.color.mul(l.intensity)
+}
+
+// attenuation returns the factor applied to the color of the light at distance d.
+func (l Light) attenuation(d float32) float32 {
+	if l.constant == 0 && l.linear == 0 && l.quadratic == 0 {
+		return 1
+	}
+	return 1 / (l.constant + l.linear*d + l.quadratic*d*d)
+}
+
+func (l Light) illuminate(p Vec3f) (Vec3f, Vec3f, float32) {
+	toLight := Sub(l.position, p)
+	dist := toLight.norme()
+	return toLight.mul(1 / dist), l.radiance().mul(l.attenuation(dist)), dist
+}
+
+// ------------------
+// DirectionalLight is an infinitely distant light (like the sun) whose rays are all
+// parallel to direction.
+type DirectionalLight struct {
+	direction Vec3f
+	color     Vec3f
+}
+
+func (l DirectionalLight) illuminate(p Vec3f) (Vec3f, Vec3f, float32) {
+	return l.direction.inverte().normalized(), l.color, float32(math.Inf(1))
+}
+
+// ------------------
+// SpotLight is a point light at position only lighting inside a cone around direction.
+// Points within innerAngle (in degrees) of the axis are fully lit, the intensity then
+// fades smoothly until outerAngle, outside of which nothing is lit.
+type SpotLight struct {
+	position, direction    Vec3f
+	color                  Vec3f
+	innerAngle, outerAngle float32
+}
+
+func (l SpotLight) illuminate(p Vec3f) (Vec3f, Vec3f, float32) {
+	toLight := Sub(l.position, p)
+	dist := toLight.norme()
+	L := toLight.mul(1 / dist)
+
+	// Cosinus de l'angle entre l'axe du spot et le vecteur lumière -> point
+	cosAngle := Dot(L.inverte(), l.direction.normalized())
+	cosInner := float32(math.Cos(float64(l.innerAngle) * math.Pi / 180))
+	cosOuter := float32(math.Cos(float64(l.outerAngle) * math.Pi / 180))
+
+	return L, l.color.mul(smoothstep(cosOuter, cosInner, cosAngle)), dist
+}
+
+// ------------------
+// AreaLight is a rectangular light going from corner along the edges u and v.
+// Its shadows are soft: the visibility of a point is estimated by casting samples
+// shadow rays towards random points of the rectangle.
+type AreaLight struct {
+	corner, u, v Vec3f
+	color        Vec3f
+	samples      int
+}
+
+func (l AreaLight) illuminate(p Vec3f) (Vec3f, Vec3f, float32) {
+	center := Add(l.corner, Add(l.u, l.v).mul(0.5))
+	toLight := Sub(center, p)
+	dist := toLight.norme()
+	return toLight.mul(1 / dist), l.color, dist
+}
+
+// quad returns the rectangle of the light.
+func (l AreaLight) quad() Quad {
+	return Quad{corner: l.corner, u: l.u, v: l.v}
+}
+
+func (l AreaLight) samplePoint(rng *rand.Rand) Vec3f {
+	return l.quad().at(rng.Float32(), rng.Float32())
+}
+
+func (l AreaLight) sampleCount() int {
+	return max(l.samples, 1)
+}
+
+// extendedLight is implemented by lights with an extent, whose visibility
+// is estimated by sampling points on their surface.
+type extendedLight interface {
+	LightSource
+	// samplePoint returns a random point on the surface of the light.
+	samplePoint(rng *rand.Rand) Vec3f
+	// sampleCount returns the number of shadow rays to cast towards the light.
+	sampleCount() int
+}
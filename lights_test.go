@@ -0,0 +1,96 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDirectionalLightDiffuseAngle(t *testing.T) {
+	diffuse := func(direction Vec3f) float32 {
+		scene := Scene{}
+		scene.addElement(Plane{point: Vec3f{}, normal: Vec3f{0, 1, 0}, Material: Lambert{Vec3f{1, 1, 1}}})
+		scene.addLight(DirectionalLight{direction: direction, color: Vec3f{1, 1, 1}})
+		return shade(t, scene, Vec3f{0, 1, 0}, Vec3f{0, -1, 0}).x
+	}
+	vertical := diffuse(Vec3f{0, -1, 0})
+	oblique := diffuse(Vec3f{1, -1, 0})
+	if vertical <= 0 {
+		t.Fatalf("diffuse under a vertical light = %v, want positive", vertical)
+	}
+	// Loi de Lambert : la diffuse suit le cosinus de l'angle d'incidence
+	if want := vertical * float32(math.Cos(math.Pi/4)); !approx(oblique, want, 1e-5) {
+		t.Errorf("diffuse under a light at 45° = %v, want %v", oblique, want)
+	}
+}
+
+func TestSpotLightCone(t *testing.T) {
+	spot := SpotLight{
+		position:   Vec3f{0, 10, 0},
+		direction:  Vec3f{0, -1, 0},
+		color:      Vec3f{1, 1, 1},
+		innerAngle: 20,
+		outerAngle: 30,
+	}
+	if _, c, _ := spot.illuminate(Vec3f{}); c != (Vec3f{1, 1, 1}) {
+		t.Errorf("color on the axis = %v, want the full intensity", c)
+	}
+	// Point à 31° de l'axe, juste hors du cône extérieur
+	x := 10 * float32(math.Tan(31*math.Pi/180))
+	if _, c, _ := spot.illuminate(Vec3f{x, 0, 0}); c != (Vec3f{}) {
+		t.Errorf("color just outside the outer cone = %v, want zero", c)
+	}
+	// Entre les deux cônes, l'intensité décroît
+	x = 10 * float32(math.Tan(25*math.Pi/180))
+	if _, c, _ := spot.illuminate(Vec3f{x, 0, 0}); c.x <= 0 || c.x >= 1 {
+		t.Errorf("color between the cones = %v, want a partial intensity", c)
+	}
+}
+
+func TestQuadraticAttenuation(t *testing.T) {
+	diffuse := func(height float32) float32 {
+		scene := Scene{}
+		scene.addElement(Plane{point: Vec3f{}, normal: Vec3f{0, 1, 0}, Material: Lambert{Vec3f{1, 1, 1}}})
+		scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{0, height, 0}, quadratic: 1})
+		return shade(t, scene, Vec3f{0, 0.5, 0}, Vec3f{0, -1, 0}).x
+	}
+	near, far := diffuse(2), diffuse(4)
+	if !approx(far, near/4, 1e-6) {
+		t.Errorf("diffuse at twice the distance = %v, want a quarter of %v", far, near)
+	}
+}
+
+func TestAreaLightPenumbra(t *testing.T) {
+	light := AreaLight{corner: Vec3f{-1, 5, -1}, u: Vec3f{2, 0, 0}, v: Vec3f{0, 0, 2}, color: Vec3f{1, 1, 1}, samples: 64}
+	scene := Scene{}
+	scene.addLight(light)
+	// Écran cachant la moitié x < 0 de la lumière vue depuis l'origine
+	scene.addElement(Quad{corner: Vec3f{-10, 2.5, -10}, u: Vec3f{10, 0, 0}, v: Vec3f{0, 0, 20}, Material: Lambert{Vec3f{1, 1, 1}}})
+
+	v := scene.visibility(light, Vec3f{}, testContext())
+	if v <= 0 || v >= 1 {
+		t.Fatalf("visibility in the penumbra = %v, want strictly between 0 and 1", v)
+	}
+	if !approx(v, 0.5, 0.2) {
+		t.Errorf("visibility in the penumbra = %v, want about half", v)
+	}
+	if v := scene.visibility(light, Vec3f{20, 0, 0}, testContext()); v != 1 {
+		t.Errorf("visibility away from the screen = %v, want 1", v)
+	}
+}
+
+func TestLightIntensityScalesDiffuse(t *testing.T) {
+	diffuse := func(intensity float32) float32 {
+		scene := Scene{}
+		scene.addElement(Plane{point: Vec3f{}, normal: Vec3f{0, 1, 0}, Material: Lambert{Vec3f{0.5, 0.5, 0.5}}})
+		scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{0, 2, 0}, intensity: intensity})
+		return shade(t, scene, Vec3f{0, 1, 0}, Vec3f{0, -1, 0}).x
+	}
+	single, double := diffuse(1), diffuse(2)
+	if single <= 0 || !approx(double, 2*single, 1e-6) {
+		t.Errorf("diffuse = %v at intensity 2, want twice %v at intensity 1", double, single)
+	}
+	// Une intensité nulle vaut 1
+	if unset := diffuse(0); unset != single {
+		t.Errorf("diffuse = %v with the intensity unset, want %v", unset, single)
+	}
+}
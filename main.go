@@ -1,66 +1,561 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"flag"
+	"fmt"
 	"image"
 	"image/color"
+	"image/jpeg"
 	"image/png"
-	"log"
+	"io"
 	"math"
+	"math/rand"
 	"os"
+	"path/filepath"
+	"runtime"
 	"runtime/pprof"
+	"strings"
+	"sync"
 )
 
+// Image holds the linear (HDR) colors of a rendered frame; they are only quantized
+// to 8 bits per channel when the image is encoded.
 type Image struct {
-	frameBuffer   []rgbRepresentation
+	frameBuffer   []Vec3f
 	width, height int
+	// Distance parcourue par le rayon central de chaque pixel jusqu'au premier objet,
+	// +Inf si aucun n'est touché ; nil si RenderOptions.depth n'est pas activée
+	depthBuffer []float32
 }
 
-func (i Image) save(path string) error {
-	// Création de l'image
+// applyGamma gamma-corrects the frame buffer in place, raising each linear channel
+// to the power 1/gamma. A gamma of 1 leaves the image untouched.
+func (i Image) applyGamma(gamma float32) {
+	if gamma <= 0 || gamma == 1 {
+		return
+	}
+	for idx, v := range i.frameBuffer {
+		i.frameBuffer[idx] = Vec3f{Pow(v.x, 1/gamma), Pow(v.y, 1/gamma), Pow(v.z, 1/gamma)}
+	}
+}
+
+// toneMapOperator returns the tone mapping curve named mode: "reinhard" (c/(1+c)),
+// "aces" (Narkowicz's fit of the ACES filmic curve, saturated to [0, 1]), or nil for "none".
+func toneMapOperator(mode string) (func(c float32) float32, error) {
+	switch mode {
+	case "", "none":
+		return nil, nil
+	case "reinhard":
+		return func(c float32) float32 { return c / (1 + c) }, nil
+	case "aces":
+		// La courbe tend vers 2.51/2.43 : elle est saturée à 1 comme dans la formule d'origine
+		return func(c float32) float32 { return min(max((c*(2.51*c+0.03))/(c*(2.43*c+0.59)+0.14), 0), 1) }, nil
+	}
+	return nil, fmt.Errorf("unknown tone mapping operator %q: expected none, reinhard or aces", mode)
+}
+
+// toneMap compresses the colors of the frame buffer in place using the operator named
+// mode (see toneMapOperator). It must be applied before gamma correction.
+func (i Image) toneMap(mode string) error {
+	op, err := toneMapOperator(mode)
+	if err != nil || op == nil {
+		return err
+	}
+
+	for idx, v := range i.frameBuffer {
+		i.frameBuffer[idx] = Vec3f{op(v.x), op(v.y), op(v.z)}
+	}
+	return nil
+}
+
+// downsample returns the image reduced by factor in both directions, each pixel
+// being the average of a factor × factor block of the source. When the size is not
+// a multiple of factor, the blocks of the last column and row are cropped to the
+// image. The depth buffer keeps the nearest depth of each block. It must be applied
+// to the linear colors, before tone mapping and gamma correction.
+func (i Image) downsample(factor int) Image {
+	if factor <= 1 {
+		return i
+	}
+	width := (i.width + factor - 1) / factor
+	height := (i.height + factor - 1) / factor
+	out := Image{frameBuffer: make([]Vec3f, width*height), width: width, height: height}
+	if i.depthBuffer != nil {
+		out.depthBuffer = make([]float32, width*height)
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var acc Accumulator
+			depth := float32(math.Inf(1))
+			for sy := y * factor; sy < min((y+1)*factor, i.height); sy++ {
+				for sx := x * factor; sx < min((x+1)*factor, i.width); sx++ {
+					acc.add(i.frameBuffer[sy*i.width+sx])
+					if i.depthBuffer != nil {
+						depth = min(depth, i.depthBuffer[sy*i.width+sx])
+					}
+				}
+			}
+			out.frameBuffer[y*width+x] = acc.mean()
+			if out.depthBuffer != nil {
+				out.depthBuffer[y*width+x] = depth
+			}
+		}
+	}
+	return out
+}
+
+// toRGBA quantizes the frame buffer to a standard opaque RGBA image.
+func (i Image) toRGBA() *image.RGBA {
 	img := image.NewRGBA(image.Rect(0, 0, i.width, i.height))
 	for y := 0; y < i.height; y++ {
 		for x := 0; x < i.width; x++ {
 			idx := (y*i.width + x)
-			r, g, b := i.frameBuffer[idx].r, i.frameBuffer[idx].b, i.frameBuffer[idx].g
+			c := clampColor(i.frameBuffer[idx])
+			r, g, b := c.r, c.g, c.b
 			img.Set(x, y, color.RGBA{r, g, b, 255})
 		}
 	}
+	return img
+}
+
+// defaultJPEGQuality is the quality of the JPEG images encoded by encode.
+const defaultJPEGQuality = 90
+
+// encode writes the image to w in format ("png", "jpeg" or "ppm", see outputFormat),
+// so that it can be sent anywhere and not only saved to a file.
+func (i Image) encode(w io.Writer, format string) error {
+	return i.encodeQuality(w, format, defaultJPEGQuality)
+}
+
+// encodeQuality encodes the image like encode, quality ranging from 1 to 100 being
+// only used by lossy formats.
+func (i Image) encodeQuality(w io.Writer, format string, quality int) error {
+	switch format {
+	case "png":
+		return png.Encode(w, i.toRGBA())
+	case "jpeg":
+		return jpeg.Encode(w, i.toRGBA(), &jpeg.Options{Quality: quality})
+	case "ppm":
+		// PPM binaire (P6) : un court en-tête texte suivi des octets RGB bruts
+		bw := bufio.NewWriter(w)
+		fmt.Fprintf(bw, "P6\n%d %d\n255\n", i.width, i.height)
+		for _, v := range i.frameBuffer {
+			c := clampColor(v)
+			bw.Write([]byte{c.r, c.g, c.b})
+		}
+		return bw.Flush()
+	}
+	return fmt.Errorf("unsupported image format %q: expected png, jpeg or ppm", format)
+}
 
-	pngFile, err := os.Create(path)
+// writeFile creates the file at path and fills it with write. An error closing the
+// file is reported too, since buffered data may only be written then.
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
 	if err != nil {
 		return err
 	}
-	defer pngFile.Close()
-	if err := png.Encode(pngFile, img); err != nil {
+	if err := write(f); err != nil {
+		f.Close()
 		return err
 	}
-	return nil
+	return f.Close()
 }
 
-// ------------------
-type Light struct {
-	color    Vec3f
-	position Vec3f
+// save encodes the image as a PNG file.
+func (i Image) save(path string) error {
+	return writeFile(path, func(w io.Writer) error {
+		return i.encode(w, "png")
+	})
+}
+
+// saveJPEG encodes the image as a JPEG file, quality ranging from 1 to 100.
+func (i Image) saveJPEG(path string, quality int) error {
+	return writeFile(path, func(w io.Writer) error {
+		return i.encodeQuality(w, "jpeg", quality)
+	})
+}
+
+// savePPM encodes the image as a binary PPM (P6) file.
+func (i Image) savePPM(path string) error {
+	return writeFile(path, func(w io.Writer) error {
+		return i.encode(w, "ppm")
+	})
+}
+
+// saveDepthPNG encodes the depth buffer as a grayscale PNG file, the nearest hit
+// being white and the farthest one black. Pixels hitting nothing are black too.
+func (i Image) saveDepthPNG(path string) error {
+	if i.depthBuffer == nil {
+		return errors.New("the image has no depth buffer")
+	}
+
+	// Intervalle des profondeurs finies, ramené à [0, 1]
+	near, far := float32(math.Inf(1)), float32(math.Inf(-1))
+	for _, d := range i.depthBuffer {
+		if !math.IsInf(float64(d), 0) {
+			near, far = min(near, d), max(far, d)
+		}
+	}
+	img := image.NewGray(image.Rect(0, 0, i.width, i.height))
+	for idx, d := range i.depthBuffer {
+		if math.IsInf(float64(d), 0) {
+			continue
+		}
+		g := float32(1)
+		if far > near {
+			g = 1 - (d-near)/(far-near)
+		}
+		img.Pix[idx] = uint8(g*255 + 0.5)
+	}
+
+	return writeFile(path, func(w io.Writer) error {
+		return png.Encode(w, img)
+	})
+}
+
+// outputFormat returns the image format ("png", "jpeg" or "ppm") matching the extension of path.
+func outputFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return "png", nil
+	case ".jpg", ".jpeg":
+		return "jpeg", nil
+	case ".ppm":
+		return "ppm", nil
+	}
+	return "", fmt.Errorf("unsupported output format %q: expected .png, .jpg, .jpeg or .ppm", filepath.Ext(path))
+}
+
+// saveAs saves the image using the encoder matching the extension of path.
+// quality is only used by lossy formats.
+func (i Image) saveAs(path string, quality int) error {
+	format, err := outputFormat(path)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, func(w io.Writer) error {
+		return i.encodeQuality(w, format, quality)
+	})
 }
 
 // --------------------------------
+// Scene holds the objects and the lights to render. It is only built before the
+// render: the rendering goroutines share it and must only read it, so materials and
+// objects never modify the scene nor themselves. What the goroutines mutate is their
+// own (random sources, pixels of their tiles) or atomic (counters).
 type Scene struct {
-	objects      []GeometricObject
-	lights       []Light
+	// Objets indexés par leur identifiant ; un objet retiré laisse une entrée nil
+	// pour que les identifiants suivants restent valides
+	objects []GeometricObject
+	// Clé de départage de chaque objet (voir tieBreakKey), calculée à son ajout
+	keys         []uint64
+	lights       []LightSource
 	ambiantLight Vec3f
+	// Structure accélératrice (BVH ou grille), nil tant que buildBVH ou buildGrid
+	// n'a pas été appelée
+	accel accelerator
+	// Couleurs du fond pour les rayons qui ne touchent aucun objet,
+	// interpolées verticalement selon la direction du rayon
+	backgroundBottom, backgroundTop Vec3f
+	// Image de l'environnement remplaçant les couleurs du fond, nil si aucune ;
+	// elle remplace aussi la lumière ambiante si environmentLighting est vrai
+	environment         *Environment
+	environmentLighting bool
+	// Brouillard, désactivé si sa densité est nulle
+	fog Fog
+	// Occlusion ambiante, désactivée si aoSamples vaut 0
+	aoSamples int
+	aoRadius  float32
+	// Compteurs du rendu en cours, nil en dehors de Render
+	counters *renderCounters
+}
+
+// setAmbient sets the color of the ambient light lighting every object of the scene.
+func (s *Scene) setAmbient(c Vec3f) {
+	s.ambiantLight = c
+}
+
+// setBackground sets a uniform color for the rays hitting no object.
+func (s *Scene) setBackground(c Vec3f) {
+	s.backgroundBottom = c
+	s.backgroundTop = c
+}
+
+// setSkyGradient sets a vertical gradient for the rays hitting no object, going from
+// bottom for rays pointing straight down to top for rays pointing straight up.
+func (s *Scene) setSkyGradient(bottom, top Vec3f) {
+	s.backgroundBottom = bottom
+	s.backgroundTop = top
+}
+
+// Fog makes distant objects fade into color. The fraction of fog mixed into the
+// color of an object at distance t is 1 - exp(-density*t).
+type Fog struct {
+	color   Vec3f
+	density float32
+}
+
+// apply blends c, seen at distance t, towards the color of the fog.
+func (f Fog) apply(c Vec3f, t float32) Vec3f {
+	if f.density <= 0 {
+		return c
+	}
+	return Lerp(c, f.color, 1-float32(math.Exp(float64(-f.density*t))))
+}
+
+// setFog enables distance fog on the scene; a density of 0 disables it.
+func (s *Scene) setFog(color Vec3f, density float32) {
+	s.fog = Fog{color, density}
 }
 
-func (s *Scene) addLight(l Light) {
+// backgroundColor returns the color seen by a ray of direction rd hitting no object.
+func (s Scene) backgroundColor(rd Vec3f) Vec3f {
+	if s.environment != nil {
+		return s.environment.sample(rd)
+	}
+	a := 0.5 * (rd.normalized().y + 1)
+	return Lerp(s.backgroundBottom, s.backgroundTop, a)
+}
+
+func (s *Scene) addLight(l LightSource) {
 	s.lights = append(s.lights, l)
 }
-func (s *Scene) addElement(g GeometricObject) {
+
+// addElement adds an object to the scene and returns its identifier, which stays
+// valid until the object is removed.
+func (s *Scene) addElement(g GeometricObject) int {
 	s.objects = append(s.objects, g)
+	s.keys = append(s.keys, tieBreakKey(g))
+	s.accel = nil
+	return len(s.objects) - 1
+}
+
+// removeElement removes the object of identifier id from the scene. It returns
+// false if there is no such object.
+func (s *Scene) removeElement(id int) bool {
+	if id < 0 || id >= len(s.objects) || s.objects[id] == nil {
+		return false
+	}
+	s.objects[id] = nil
+	s.accel = nil
+	return true
+}
+
+// replaceElement replaces the object of identifier id by g, which keeps the same
+// identifier. It returns false if there is no such object.
+func (s *Scene) replaceElement(id int, g GeometricObject) bool {
+	if id < 0 || id >= len(s.objects) || s.objects[id] == nil || g == nil {
+		return false
+	}
+	s.objects[id] = g
+	s.keys[id] = tieBreakKey(g)
+	s.accel = nil
+	return true
+}
+
+// elements returns the objects of the scene, skipping the removed ones.
+func (s Scene) elements() []GeometricObject {
+	objects := make([]GeometricObject, 0, len(s.objects))
+	for _, object := range s.objects {
+		if object != nil {
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+// bounds returns the box containing every bounded object of the scene. Unbounded
+// objects such as planes are skipped; the box is empty (zero) if no object is bounded.
+func (s Scene) bounds() AABB {
+	var box AABB
+	found := false
+	for _, object := range s.elements() {
+		b := object.bounds()
+		if !b.isFinite() {
+			continue
+		}
+		if !found {
+			box, found = b, true
+			continue
+		}
+		box = union(box, b)
+	}
+	return box
+}
+
+// indexedObject is an object of the scene along with its identifier.
+type indexedObject struct {
+	object GeometricObject
+	id     int
+	key    uint64
+}
+
+// nearestHit is the nearest hit found so far while testing the objects of the scene.
+type nearestHit struct {
+	object GeometricObject
+	id     int
+	key    uint64
+	t      float32
+	// Partie touchée si l'objet est composé de parties (voir partedObject), nil sinon
+	part GeometricObject
+}
+
+// newNearestHit returns the nearest hit before any object has been tested.
+func newNearestHit() nearestHit {
+	return nearestHit{id: math.MaxInt, t: 9999999999.0}
+}
+
+// test intersects the ray with o and keeps it if it is hit nearer than the nearest hit
+// so far. Hits at the same distance, on coincident surfaces, go to the object of
+// smallest tie-break key (see tieBreakKey), then of smallest identifier when the keys
+// are equal, so that the result depends neither on the order in which the objects are
+// tested (by the linear scan, the BVH or the grid) nor, unless the objects are
+// identical, on the order in which they were added.
+func (h *nearestHit) test(o indexedObject, ro, rd Vec3f) {
+	isIntersected, t, part := intersectPart(o.object, ro, rd)
+	if !isIntersected || t > h.t {
+		return
+	}
+	if t == h.t && h.object != nil && (o.key > h.key || (o.key == h.key && o.id > h.id)) {
+		return
+	}
+	h.object, h.id, h.key, h.t, h.part = o.object, o.id, o.key, t, part
+}
+
+// accelerator speeds up the search of the nearest object hit by a ray, avoiding
+// testing every object of the scene.
+type accelerator interface {
+	// traverse returns the nearest hit of the ray, whose object is nil if no object
+	// is hit, adding the number of intersection tests made to tests.
+	traverse(ro, rd Vec3f, tests *int) nearestHit
+}
+
+// buildBVH builds the bounding volume hierarchy used to speed up intersection tests.
+// It must be called again after adding, removing or replacing elements.
+func (s *Scene) buildBVH() {
+	s.accel = Build(s.objects, s.keys)
+}
+
+// buildGrid builds a uniform grid used to speed up intersection tests instead of a
+// BVH. It must be called again after adding, removing or replacing elements.
+func (s *Scene) buildGrid() {
+	s.accel = BuildGrid(s.objects, s.keys)
+}
+
+// intersect returns the record of the nearest hit of the ray, and false if no
+// object is hit.
+func (s Scene) intersect(ro, rd Vec3f) (Hit, bool) {
+	nearest := s.nearestHit(ro, rd)
+	if nearest.object == nil {
+		return Hit{}, false
+	}
+	return hitRecord(nearest.object, nearest.part, ro, rd, nearest.t), true
+}
+
+// nearest returns the nearest object hit by the ray and the distance to it,
+// or nil if no object is hit.
+func (s Scene) nearest(ro, rd Vec3f) (GeometricObject, float32) {
+	hit := s.nearestHit(ro, rd)
+	return hit.object, hit.t
+}
+
+// nearestHit returns the nearest hit of the ray, its object being nil if no object is
+// hit. It uses the BVH or the grid when one has been built.
+func (s Scene) nearestHit(ro, rd Vec3f) nearestHit {
+	tests := 0
+	defer func() { s.counters.addIntersectionTests(tests) }()
+	if s.accel != nil {
+		return s.accel.traverse(ro, rd, &tests)
+	}
+	nearest := newNearestHit()
+	for id, object := range s.objects {
+		if object == nil || missesBoundingSphere(object, ro, rd) {
+			continue
+		}
+		tests++
+		nearest.test(indexedObject{object, id, s.keys[id]}, ro, rd)
+	}
+	return nearest
+}
+
+// surfaceEpsilon is the default offset applied along the normal to the origin of the
+// rays spawned from a surface (shadow, reflected, refracted and ambient occlusion
+// rays), so that they do not intersect the surface they leave because of rounding
+// errors, which shows as shadow acne.
+const surfaceEpsilon = 1e-3
+
+// visibility returns the fraction of the light reaching the point from, between 0 (fully
+// in shadow) and 1. For extended lights, it is the fraction of shadow rays cast towards
+// random points of the light that are not blocked, which produces penumbras. The shadow
+// rays are taken from the budget of ctx: when it is exhausted, fewer samples are cast,
+// and the light is taken as blocked if none can be.
+func (s Scene) visibility(light LightSource, from Vec3f, ctx rayContext) float32 {
+	if extended, ok := light.(extendedLight); ok {
+		samples := ctx.spend(extended.sampleCount())
+		if samples == 0 {
+			return 0
+		}
+		visible := 0
+		s.counters.addShadowRays(samples)
+		for i := 0; i < samples; i++ {
+			if !s.isOccluded(from, extended.samplePoint(ctx.rng)) {
+				visible++
+			}
+		}
+		return float32(visible) / float32(samples)
+	}
+
+	if ctx.spend(1) == 0 {
+		return 0
+	}
+	L, _, dist := light.illuminate(from)
+	s.counters.addShadowRays(1)
+	if s.isBlocked(from, L, dist) {
+		return 0
+	}
+	return 1
+}
+
+// isOccluded reports whether any object of the scene lies on the segment going
+// from the point from to the point to.
+func (s Scene) isOccluded(from, to Vec3f) bool {
+	dir := Sub(to, from)
+	dist := dir.norme()
+	return s.isBlocked(from, dir.mul(1/dist), dist)
+}
+
+// isBlocked reports whether any object of the scene is hit by the ray of origin from
+// and direction dir closer than dist, which may be +Inf.
+func (s Scene) isBlocked(from, dir Vec3f, dist float32) bool {
+	tests := 0
+	defer func() { s.counters.addIntersectionTests(tests) }()
+	if s.accel != nil {
+		hit := s.accel.traverse(from, dir, &tests)
+		return hit.object != nil && hit.t < dist
+	}
+	for _, object := range s.objects {
+		if object == nil || missesBoundingSphere(object, from, dir) {
+			continue
+		}
+		tests++
+		isIntersected, t := object.isIntersectedByRay(from, dir)
+		if isIntersected && t < dist {
+			return true
+		}
+	}
+	return false
 }
 
 // ----------------------------------
+// Materials shade the points of the objects. render is called concurrently by the
+// rendering goroutines: it must not mutate the material nor the scene, and draws its
+// random numbers from ctx.rng, which belongs to the calling goroutine.
 type Materials interface {
-	render(rio, rdi, n Vec3f, t float32, scene Scene) rgbRepresentation
+	render(rdi Vec3f, hit Hit, scene Scene, ctx rayContext) Vec3f
 }
 
 // Lambert represents a Lambertian reflectance model which is used in computer graphics
@@ -72,32 +567,130 @@ type Lambert struct {
 }
 
 // render calculates the Lambertian reflectance for a given point in the scene.
-// It takes the incoming ray direction (rio), the reflected ray direction (rdi),
-// the normal at the intersection point (n), the intersection distance (t), and
-// the scene information (scene). It returns the RGB representation of the
+// It takes the incident ray direction (rdi), the record of the hit (hit) and
+// the scene information (scene). It returns the linear color of the
 // reflected light.
 //
 // Parameters:
-// - rio: Vec3f representing the incoming ray direction.
-// - rdi: Vec3f representing the reflected ray direction.
-// - n: Vec3f representing the normal at the intersection point.
-// - t: float32 representing the intersection distance.
+// - rdi: Vec3f representing the incident ray direction.
+// - hit: Hit holding the intersection point, the normal there and the intersection distance.
 // - scene: Scene containing the scene information including lights.
+// - ctx: rayContext of the ray being shaded (recursion depth, random source).
 //
 // Returns:
-// - rgbRepresentation: The RGB representation of the reflected light.
-func (l Lambert) render(rio, rdi, n Vec3f, t float32, scene Scene) rgbRepresentation {
+// - Vec3f: The linear color of the reflected light.
+func (l Lambert) render(rdi Vec3f, hit Hit, scene Scene, ctx rayContext) Vec3f {
 	// res := Mul(l.kd, scene.lights[0].color) // res := l.kd
-	// return rgbRepresentation{uint8(res.x), uint8(res.y), uint8(res.z)}
-	omega := Add(rio, rdi.mul(t))
-	omega = Add(scene.lights[0].position, omega.inverte())
-	Li := Mul(l.kd, scene.lights[0].color.mul(Dot(n, omega))).mul(1 / 3.14)
-	return rgbRepresentation{uint8(Li.x * 255), uint8(Li.y * 255), uint8(Li.z * 255)}
+	omega, n := hit.point, hit.normal
+	Li := Vec3f{}
+	for _, light := range scene.lights {
+		L, I, _ := light.illuminate(omega)
+		// Une lumière derrière la surface ne l'éclaire pas (et ne doit pas l'assombrir)
+		NdotL := max(Dot(n, L), 0)
+		if NdotL == 0 {
+			continue
+		}
+		// Part de la lumière qui n'est pas masquée par un objet
+		visibility := scene.visibility(light, ctx.offset(omega, n), ctx)
+		if visibility == 0 {
+			continue
+		}
+		Li = Add(Li, Mul(l.kd, I.mul(NdotL*visibility)).mul(1/3.14))
+	}
+	// Lambert n'a pas de terme ambiant, sauf éclairé par l'environnement :
+	// l'occlusion ambiante assombrit la diffuse
+	if scene.usesEnvironmentLighting() {
+		Li = Add(Li, Mul(l.kd, scene.ambientAt(n)))
+	}
+	Li = Li.mul(scene.ambientOcclusion(omega, n, ctx))
+	return Li
 }
 
 type GeometricObject interface {
 	isIntersectedByRay(ro, rd Vec3f) (bool, float32)
-	render(rio, rdi Vec3f, t float32, scene Scene) rgbRepresentation
+	// surface returns the normal given to the material at the point p of the object hit
+	// by a ray of direction rd, and the material itself.
+	surface(p, rd Vec3f) (Vec3f, Materials)
+	// bounds returns the bounding box of the object, infiniteAABB if it is unbounded.
+	bounds() AABB
+	// boundingSphere returns the center and the radius of a sphere containing the
+	// object, checked before the exact intersection test. The radius is +Inf if the
+	// object is unbounded.
+	boundingSphere() (Vec3f, float32)
+}
+
+// missesBoundingSphere reports whether the ray (ro, rd) certainly misses object,
+// its line passing outside of the bounding sphere of the object or the sphere lying
+// entirely behind the origin of the ray.
+func missesBoundingSphere(object GeometricObject, ro, rd Vec3f) bool {
+	center, radius := object.boundingSphere()
+	if math.IsInf(float64(radius), 1) {
+		return false
+	}
+	oc := Sub(center, ro)
+	// Distance au carré du centre au rayon, la projection du centre sur celui-ci
+	d2 := Sub(oc, oc.project(rd)).lengthSquared()
+	if d2 > radius*radius {
+		return true
+	}
+	return Dot(oc, rd) < 0 && oc.lengthSquared() > radius*radius
+}
+
+// Hit is the record of the intersection of a ray with an object, handed to the
+// material of the object to shade it.
+type Hit struct {
+	// Distance le long du rayon, point touché et normale donnée au matériau
+	t      float32
+	point  Vec3f
+	normal Vec3f
+
+	object   GeometricObject
+	material Materials
+
+	// Coordonnées de texture du point, si l'objet en définit (hasUV)
+	uv    Vec2f
+	hasUV bool
+}
+
+// uvMapper is implemented by the objects defining texture coordinates on their surface.
+type uvMapper interface {
+	// uvAt returns the texture coordinates, in [0, 1]², of the point p of the surface.
+	uvAt(p Vec3f) Vec2f
+}
+
+// partedObject is implemented by the objects made of parts, such as meshes, whose
+// surface is the one of the part hit.
+type partedObject interface {
+	// nearestPart returns the part hit first by the ray and the distance to it, or nil
+	// if no part is hit. A part made of parts itself gives its own part hit.
+	nearestPart(ro, rd Vec3f) (GeometricObject, float32)
+}
+
+// intersectPart intersects the ray with object like isIntersectedByRay, also returning
+// the part hit when object is made of parts, nil otherwise.
+func intersectPart(object GeometricObject, ro, rd Vec3f) (bool, float32, GeometricObject) {
+	if parted, ok := object.(partedObject); ok {
+		part, t := parted.nearestPart(ro, rd)
+		return part != nil, t, part
+	}
+	isIntersected, t := object.isIntersectedByRay(ro, rd)
+	return isIntersected, t, nil
+}
+
+// hitRecord fills the record of the ray (ro, rd) hitting object at the distance t. The
+// normal and the material are the ones of part, the part of object hit, when it is not nil.
+func hitRecord(object, part GeometricObject, ro, rd Vec3f, t float32) Hit {
+	p := Add(ro, rd.mul(t))
+	source := object
+	if part != nil {
+		source = part
+	}
+	n, m := source.surface(p, rd)
+	hit := Hit{t: t, point: p, normal: n, object: object, material: m}
+	if mapper, ok := source.(uvMapper); ok {
+		hit.uv, hit.hasUV = mapper.uvAt(p), true
+	}
+	return hit
 }
 
 // -------------------------------
@@ -108,17 +701,30 @@ type Sphere struct {
 	Material Materials
 }
 
-// render calculates the color representation of a sphere when rendered in a scene.
-// It takes the incident ray origin (rio), the incident ray direction (rdi),
-// the intersection distance (t), and the scene as parameters.
-// The normal on a sphere is the inverse of the incident ray direction.
-// This function returns the RGB representation of the rendered sphere.
-func (s Sphere) render(rio, rdi Vec3f, t float32, scene Scene) rgbRepresentation {
+// surface returns the normal on the sphere, the normalized vector going from its
+// center to the point p, and its material.
+func (s Sphere) surface(p, rd Vec3f) (Vec3f, Materials) {
 	/*
-	* Le calcul de la normal sur une sphère est l'inverse du rayon incident.
-	* C'est pourquoi n = rd1.inverte()
+	* La normale en un point d'une sphère est le vecteur centre -> point d'intersection.
 	 */
-	return s.Material.render(rio, rdi, rdi.inverte(), t, scene)
+	return p.Sub(s.position).normalized(), s.Material
+}
+
+func (s Sphere) bounds() AABB {
+	r := Vec3f{s.radius, s.radius, s.radius}
+	return AABB{min: Sub(s.position, r), max: Add(s.position, r)}
+}
+
+// uvAt returns the spherical coordinates of the point p of the sphere: u goes around
+// the vertical axis and v from the top (0) to the bottom (1) (see sphericalUV).
+func (s Sphere) uvAt(p Vec3f) Vec2f {
+	u, v := sphericalUV(p.Sub(s.position).normalized())
+	return Vec2f{u, v}
+}
+
+// boundingSphere returns the sphere itself.
+func (s Sphere) boundingSphere() (Vec3f, float32) {
+	return s.position, s.radius
 }
 
 // isIntersectedByRay determines if a ray intersects with the sphere.
@@ -131,23 +737,29 @@ func (s Sphere) render(rio, rdi Vec3f, t float32, scene Scene) rgbRepresentation
 //   - rd: Vec3f representing the direction of the ray.
 //
 // Returns:
-//   - bool: true if the ray intersects the sphere, false otherwise.
-//   - float32: the distance from the ray origin to the intersection point if there is an intersection, 0.0 otherwise.
+//   - bool: true if the ray intersects the sphere in front of its origin, false otherwise.
+//   - float32: the distance from the ray origin to the nearest intersection point in front of the ray if there is one, 0.0 otherwise.
 func (s Sphere) isIntersectedByRay(ro, rd Vec3f) (bool, float32) {
-	L := Add(ro, Vec3f{-s.position.x, -s.position.y, -s.position.z})
+	L := ro.Sub(s.position)
 
 	a := Dot(rd, rd)
 	b := 2.0 * Dot(rd, L)
 	c := Dot(L, L) - s.radius*s.radius
 	delta := b*b - 4.0*a*c
 
-	t0 := (-b - float32(math.Sqrt(float64(delta)))) / 2 * a
-	t1 := (-b + float32(math.Sqrt(float64(delta)))) / 2 * a
-	t := t0
-	t = min(t, t1)
+	if delta <= 0 {
+		return false, 0.0
+	}
+
+	t0 := (-b - float32(math.Sqrt(float64(delta)))) / (2 * a)
+	t1 := (-b + float32(math.Sqrt(float64(delta)))) / (2 * a)
 
-	if delta > 0 {
-		return true, t
+	// On garde la racine positive la plus proche (t0 <= t1)
+	if t0 > 0 {
+		return true, t0
+	}
+	if t1 > 0 {
+		return true, t1
 	}
 	return false, 0.0
 }
@@ -157,8 +769,120 @@ func (s Sphere) isIntersectedByRay(ro, rd Vec3f) (bool, float32) {
 // position: The position of the camera in 3D space.
 // up: The up direction vector of the camera, typically used to define the camera's orientation.
 // at: The point in 3D space where the camera is looking at.
+// orthographic: Whether the camera uses a parallel projection instead of a perspective one.
+// orthoScale: The height of the area seen by an orthographic camera, in world units.
+// fovY: The vertical field of view of a perspective camera, in degrees (defaultFovY if zero). The horizontal
+// field of view follows from the aspect ratio of the rendered image.
+// aperture: The diameter of the lens of a perspective camera; 0 for a pinhole camera where everything is sharp.
+// focusDistance: The distance from the camera to the plane in focus when aperture is positive.
+// right, vertical, forward: The orthonormal basis of the camera, precomputed by NewCamera.
 type Camera struct {
 	position, up, at Vec3f
+	fovY             float32
+
+	aperture, focusDistance float32
+
+	orthographic bool
+	orthoScale   float32
+
+	right, vertical, forward Vec3f
+}
+
+// NewCamera returns a perspective camera at position looking at target, with the given
+// up direction and vertical field of view in degrees. It precomputes the orthonormal basis
+// of the camera and returns an error if the vectors cannot define one.
+func NewCamera(position, target, up Vec3f, fov float32) (Camera, error) {
+	if err := checkCameraVectors(position, target, up); err != nil {
+		return Camera{}, err
+	}
+	forward := Sub(target, position).normalized()
+	right := cross(forward, up).normalized()
+
+	return Camera{
+		position: position,
+		up:       up,
+		at:       target,
+		fovY:     fov,
+		right:    right,
+		vertical: cross(right, forward),
+		forward:  forward,
+	}, nil
+}
+
+// AutoCamera returns a perspective camera with the default field of view framing the
+// bounded objects of the scene. It looks at the center of their bounding box along +z,
+// from far enough for the sphere enclosing the box to fit in the image.
+func AutoCamera(scene Scene) Camera {
+	box := scene.bounds()
+	center := box.centroid()
+	radius := max(Sub(box.max, box.min).norme()/2, 1e-3)
+
+	// Distance à laquelle la sphère englobante remplit le champ de vision vertical
+	distance := radius / float32(math.Sin(defaultFovY*math.Pi/360))
+	camera, _ := NewCamera(Sub(center, Vec3f{0, 0, distance}), center, Vec3f{0, 1, 0}, defaultFovY)
+	return camera
+}
+
+// checkCameraVectors returns an error if the vectors cannot define the orthonormal
+// basis of a camera at position looking at target.
+func checkCameraVectors(position, target, up Vec3f) error {
+	for _, v := range [3]Vec3f{position, target, up} {
+		for _, c := range [3]float32{v.x, v.y, v.z} {
+			if math.IsNaN(float64(c)) || math.IsInf(float64(c), 0) {
+				return fmt.Errorf("camera vector %v is not finite", v)
+			}
+		}
+	}
+	// Longueurs comparées au carré, comme normalized compare la norme à normalizeEpsilon
+	forward := Sub(target, position)
+	if forward.lengthSquared() < normalizeEpsilon*normalizeEpsilon {
+		return errors.New("camera position and target are the same point")
+	}
+	if cross(forward.normalized(), up).lengthSquared() < normalizeEpsilon*normalizeEpsilon {
+		return errors.New("camera up vector is null or parallel to the view direction")
+	}
+	return nil
+}
+
+// Validate returns an error describing the first setting of the camera that
+// prevents it from generating rays.
+func (c Camera) Validate() error {
+	if err := checkCameraVectors(c.position, c.at, c.up); err != nil {
+		return err
+	}
+	if c.fovY < 0 || c.fovY >= 180 {
+		return fmt.Errorf("camera field of view %g is not between 0 and 180 degrees", c.fovY)
+	}
+	if c.orthographic && c.orthoScale <= 0 {
+		return fmt.Errorf("orthographic camera scale %g is not positive", c.orthoScale)
+	}
+	if c.aperture > 0 && c.focusDistance <= 0 {
+		return fmt.Errorf("camera focus distance %g is not positive", c.focusDistance)
+	}
+	return nil
+}
+
+// basis returns the orthonormal basis of the camera: the right, up and forward
+// directions. It uses the basis precomputed by NewCamera when there is one.
+func (c Camera) basis() (Vec3f, Vec3f, Vec3f) {
+	if c.forward != (Vec3f{}) {
+		return c.right, c.vertical, c.forward
+	}
+	forward := c.direction()
+	right := cross(forward, c.up).normalized()
+	return right, cross(right, forward).normalized(), forward
+}
+
+// defaultFovY is the vertical field of view, in degrees, of cameras not setting one.
+const defaultFovY = 36.52
+
+// fovScale returns the height of the image plane at distance 1 from the camera.
+func (c Camera) fovScale() float32 {
+	fovY := c.fovY
+	if fovY <= 0 {
+		fovY = defaultFovY
+	}
+	return 2 * float32(math.Tan(float64(fovY)*math.Pi/360))
 }
 
 // direction calculates the direction vector of the camera by subtracting
@@ -166,35 +890,176 @@ type Camera struct {
 // vector, and returning it. The returned vector is a unit vector pointing
 // from the camera's position to its target point.
 func (c Camera) direction() Vec3f {
-	dir := Add(c.at, c.position.inverte())
+	dir := c.at.Sub(c.position)
 	return dir.mul(float32(1) / dir.norme())
 }
 
 // ------------------------------
 
+// defaultMaxDepth is the maximum number of bounces a ray can do through reflective
+// materials when the render options do not set one.
+const defaultMaxDepth = 5
+
+// rayContext carries the state of the ray being traced through renderPixel and the
+// render methods. The random source belongs to the goroutine tracing the ray.
+type rayContext struct {
+	depth    int
+	maxDepth int
+	rng      *rand.Rand
+	// Roulette russe au-delà de maxDepth au lieu d'arrêter le rayon
+	roulette bool
+	// Part de la couleur du rayon qui parvient à la caméra, entre 0 et 1
+	throughput float32
+	// Mode de débogage, vide pour un rendu normal
+	debug string
+	// Nombre de rayons que le pixel peut encore tracer, partagé par tous les rayons
+	// de l'échantillon, rayons d'ombre et d'occlusion ambiante compris ; nil si illimité
+	budget *int
+	// Décalage de l'origine des rayons partant d'une surface (voir surfaceEpsilon)
+	epsilon float32
+}
+
+// offset returns the origin of a ray leaving the point p of a surface on the side
+// of n, moved by ctx.epsilon along n so that it does not hit the surface again.
+func (ctx rayContext) offset(p, n Vec3f) Vec3f {
+	return Add(p, n.mul(ctx.epsilon))
+}
+
+// spend takes n rays from the budget of the pixel and returns the number of them that
+// may be traced, fewer than n once the budget is exhausted.
+func (ctx rayContext) spend(n int) int {
+	if ctx.budget == nil {
+		return n
+	}
+	n = min(n, max(*ctx.budget, 0))
+	*ctx.budget -= n
+	return n
+}
+
+// child returns the context of a secondary ray spawned by the current one.
+func (ctx rayContext) child() rayContext {
+	ctx.depth++
+	return ctx
+}
+
+// weighted returns the context of a ray whose color is scaled by w before reaching
+// the current one, so that Russian roulette kills it more often when w is small.
+func (ctx rayContext) weighted(w float32) rayContext {
+	ctx.throughput *= w
+	return ctx
+}
+
+// rouletteMinSurvival is the smallest survival probability of a ray going through
+// Russian roulette, so that dim rays are not all killed, which would make them noisy.
+const rouletteMinSurvival = 0.05
+
+// rouletteDepthLimit is the depth at which rays are stopped even with Russian roulette,
+// in case of scenes that reflect all the light.
+const rouletteDepthLimit = 100
+
 // renderPixel computes the color of a pixel by tracing a ray through the scene.
-// It iterates over all objects in the scene to find the closest intersection point
-// and then calculates the color at that point.
+// It looks for the closest intersection point among the objects of the scene (using the BVH or the grid if built)
+// and then calculates the color at that point, or returns the background color if no object is hit.
+// The color is then blended with the fog of the scene according to the distance travelled by the ray.
 //
 // Parameters:
 // - scene: The Scene containing all objects to be rendered.
 // - ro: The origin of the ray (Vec3f).
 // - rd: The direction of the ray (Vec3f).
+// - ctx: The context of the ray; its depth is 0 for primary rays. Rays deeper than ctx.maxDepth are black,
+// unless ctx.roulette is set: they then survive with a probability given by their throughput, and their
+// color is divided by this probability to keep the average unbiased. Rays are also black once ctx.budget
+// is exhausted.
 //
 // Returns:
-// - rgbRepresentation: The color of the pixel as an rgbRepresentation struct.
-func renderPixel(scene Scene, ro, rd Vec3f) rgbRepresentation {
-	var tmin float32
-	tmin = 9999999999.0
-	res := rgbRepresentation{}
-	for _, object := range scene.objects {
-		isIntersected, t := object.isIntersectedByRay(ro, rd)
-		if isIntersected && t < tmin {
-			tmin = t
-			res = object.render(ro, rd, t, scene)
+// - Vec3f: The linear color of the pixel.
+func renderPixel(scene Scene, ro, rd Vec3f, ctx rayContext) Vec3f {
+	weight := float32(1)
+	if ctx.depth > ctx.maxDepth {
+		if !ctx.roulette || ctx.depth > rouletteDepthLimit {
+			return Vec3f{}
+		}
+		survival := min(max(ctx.throughput, rouletteMinSurvival), 1)
+		if ctx.rng.Float32() >= survival {
+			return Vec3f{}
+		}
+		// Les rayons survivants compensent ceux qui ont été arrêtés
+		weight = 1 / survival
+		ctx.throughput *= weight
+	}
+	if ctx.spend(1) == 0 {
+		return Vec3f{}
+	}
+
+	hit, ok := scene.intersect(ro, rd)
+	if ctx.debug != "" {
+		if !ok {
+			return Vec3f{}
 		}
+		return debugColor(ctx.debug, hit, scene, ctx)
+	}
+	if !ok {
+		// Un rayon qui ne touche rien traverse une épaisseur infinie de brouillard
+		return scene.fog.apply(scene.backgroundColor(rd), float32(math.Inf(1))).mul(weight)
+	}
+	return scene.fog.apply(hit.material.render(rd, hit, scene, ctx), hit.t).mul(weight)
+}
+
+// debugDepthScale is the distance at which the gray of the depth debug mode is half white.
+const debugDepthScale = 10
+
+// lightCountRamp gives the color of the "lights" debug mode for each number of lights
+// reaching a point, from 0 (black) to len(lightCountRamp)-1 or more (white).
+var lightCountRamp = []Vec3f{
+	{0, 0, 0},
+	{0, 0, 1},
+	{0, 1, 1},
+	{0, 1, 0},
+	{1, 1, 0},
+	{1, 0, 0},
+	{1, 1, 1},
+}
+
+// debugColor returns the color of the hit in the debug mode named mode, bypassing the
+// material: "normals" maps the normal from [-1, 1] to [0, 1] on each channel,
+// "depth" gives a gray going from white at the camera to black far away, and
+// "lights" gives the color of lightCountRamp matching the number of lights reaching
+// the point, to check the coverage of the lights.
+func debugColor(mode string, hit Hit, scene Scene, ctx rayContext) Vec3f {
+	switch mode {
+	case "normals":
+		return Add(hit.normal, Vec3f{1, 1, 1}).mul(0.5)
+	case "depth":
+		g := debugDepthScale / (debugDepthScale + hit.t)
+		return Vec3f{g, g, g}
+	case "lights":
+		count := scene.reachingLights(hit, ctx)
+		return lightCountRamp[min(count, len(lightCountRamp)-1)]
 	}
-	return res
+	return Vec3f{}
+}
+
+// reachingLights returns the number of lights of the scene lighting the front of the
+// hit point that are not (fully) occluded.
+func (s Scene) reachingLights(hit Hit, ctx rayContext) int {
+	count := 0
+	from := ctx.offset(hit.point, hit.normal)
+	for _, light := range s.lights {
+		L, _, _ := light.illuminate(hit.point)
+		if Dot(L, hit.normal) > 0 && s.visibility(light, from, ctx) > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// checkDebugMode returns an error if mode is not a debug mode known to debugColor.
+func checkDebugMode(mode string) error {
+	switch mode {
+	case "", "none", "normals", "depth", "lights":
+		return nil
+	}
+	return fmt.Errorf("unknown debug mode %q: expected none, normals, depth or lights", mode)
 }
 
 // renderFrame renders a frame of the scene from the perspective of the camera onto the image.
@@ -203,32 +1068,217 @@ func renderPixel(scene Scene, ro, rd Vec3f) rgbRepresentation {
 //   - image: The Image object that contains the frame buffer where the rendered frame will be stored.
 //   - camera: The Camera object that defines the position and orientation of the camera.
 //   - scene: The Scene object that contains all the objects and lights to be rendered.
+//   - opts: The RenderOptions controlling the number of threads, of samples per pixel and the recursion depth.
+//   - region: The rectangle of pixels to render, the rest of the frame buffer being left untouched.
 //
 // The function calculates the ray direction for each pixel in the image based on the camera's position and orientation.
 // It then traces the ray through the scene to determine the color of the pixel and stores the result in the image's frame buffer.
-func renderFrame(image Image, camera Camera, scene Scene) {
+// With more than one sample per pixel, the rays are jittered within the pixel and their colors averaged
+// (anti-aliasing); a square number of samples is stratified over a grid of cells (see stratifiedOffset). When the camera has an aperture, the origins of the rays are also spread over
+// its lens (depth of field).
+func renderFrame(image Image, camera Camera, scene Scene, opts RenderOptions, region tile) {
+	opts = opts.withDefaults()
+	renderPasses(image, camera, scene, opts, newFrameState(image, opts, jitters(camera, opts.samples), region), opts.samples)
+}
+
+// jitters reports whether the rays of a render of samples samples per pixel are spread
+// within the pixel rather than going through its center: a single sample is only
+// jittered to spread the origins over the lens of the camera.
+func jitters(camera Camera, samples int) bool {
+	return samples > 1 || camera.aperture > 0
+}
+
+// frameState is what a frame keeps between the passes of a progressive render: the
+// samples accumulated in each pixel and the random source of each tile.
+type frameState struct {
+	// Rectangle de pixels rendus et tuiles le couvrant
+	region       tile
+	tiles        []tile
+	rngs         []*rand.Rand
+	accumulators []Accumulator
+	// Rayons tirés au hasard dans le pixel, ou passant par son centre
+	jitter bool
+	// Nombre total d'échantillons par pixel, qui détermine la grille de stratification
+	samples int
+	// Rayons déjà tracés dans chaque pixel, nil si leur nombre n'est pas limité
+	rays []int
+}
+
+// newFrameState returns the state of a frame of the size of image where no sample
+// has been rendered yet, covering the pixels of region. The tiles are those of the
+// whole image cropped to the region, each keeping the random source of its index in
+// the image, so that a region aligned on tiles renders as in the whole image.
+func newFrameState(image Image, opts RenderOptions, jitter bool, region tile) *frameState {
+	state := &frameState{
+		region:       region,
+		accumulators: make([]Accumulator, image.width*image.height),
+		jitter:       jitter,
+		samples:      opts.samples,
+	}
+	if opts.maxRaysPerPixel > 0 {
+		state.rays = make([]int, image.width*image.height)
+	}
+	for i, t := range splitTiles(image.width, image.height, opts.tileSize) {
+		t = tile{max(t.x0, region.x0), max(t.y0, region.y0), min(t.x1, region.x1), min(t.y1, region.y1)}
+		if t.x0 >= t.x1 || t.y0 >= t.y1 {
+			continue
+		}
+		state.tiles = append(state.tiles, t)
+		state.rngs = append(state.rngs, rand.New(rand.NewSource(tileSeed(opts.seed, i))))
+	}
+	return state
+}
+
+// cameraRays returns the function giving the origin and direction of the ray going through the point (dx, dy)
+// of the pixel (x, y) of a width × height image, dx and dy being offsets in [0, 1) within the pixel. rng is used
+// to sample the lens of the camera; without one, the ray goes through the center of the lens.
+func cameraRays(camera Camera, width, height int) func(x, y int, dx, dy float32, rng *rand.Rand) (Vec3f, Vec3f) {
 	ro := camera.position
-	cosFovy := float32(0.66)
+	cosFovy := camera.fovScale()
 
-	aspect := float32(image.width) / float32(image.height)
-	horizontal := (cross(camera.direction(), camera.up)).normalized().mul(cosFovy * aspect)
-	vertical := (cross(horizontal, camera.direction())).normalized().mul(cosFovy)
+	// Le champ de vision s'applique à la hauteur de l'image ; la largeur en est déduite
+	// avec le rapport d'aspect, de sorte que les pixels restent carrés
+	aspect := float32(width) / float32(height)
+	right, up, forward := camera.basis()
+	horizontal := right.mul(cosFovy * aspect)
+	vertical := up.mul(cosFovy)
 
-	for x := 0; x < image.width; x++ {
-		for y := 0; y < image.height; y++ {
+	return func(x, y int, dx, dy float32, rng *rand.Rand) (Vec3f, Vec3f) {
+		// Coordonnées du point sur le plan image, centrées en 0 ; les lignes de l'image
+		// vont de haut en bas alors que vertical pointe vers le haut
+		sx := (float32(x)+dx)/float32(width) - 0.5
+		sy := 0.5 - (float32(y)+dy)/float32(height)
 
-			uvx := (float32(x) + float32(0.5)) / float32(image.width)
-			uvy := (float32(y) + float32(0.5)) / float32(image.height)
+		if camera.orthographic {
+			// Projection parallèle : les rayons partent du plan image et ont tous la même direction
+			width := right.mul(camera.orthoScale * aspect)
+			height := up.mul(camera.orthoScale)
+			origin := Add(Add(ro, width.mul(sx)), height.mul(sy))
+			return origin, forward
+		}
+		rd := Add(Add(forward, horizontal.mul(sx)), vertical.mul(sy)).normalized()
+		if camera.aperture <= 0 || rng == nil {
+			return ro, rd
+		}
 
-			rd := Add(Add(camera.direction(), horizontal.mul(uvx-float32(0.5))), vertical.mul(uvy-float32(0.5))).normalized()
+		// Profondeur de champ : l'origine est tirée sur la lentille et le rayon vise
+		// le point du plan focal que le rayon sans lentille aurait touché
+		focus := Add(ro, rd.mul(camera.focusDistance/Dot(rd, forward)))
+		r := camera.aperture / 2 * float32(math.Sqrt(rng.Float64()))
+		theta := 2 * math.Pi * rng.Float64()
+		lens := Add(
+			right.mul(r*float32(math.Cos(theta))),
+			up.mul(r*float32(math.Sin(theta))),
+		)
+		origin := Add(ro, lens)
+		return origin, Sub(focus, origin).normalized()
+	}
+}
 
-			image.frameBuffer[y*image.width+x] = renderPixel(scene, ro, rd)
+// ray returns the origin and direction of the primary ray going through the center
+// of the pixel (x, y) of a width × height image and the center of the lens, for
+// picking or debugging. Rendering many rays should use cameraRays, which only builds
+// the basis of the camera once.
+func (c Camera) ray(x, y, width, height int) (Vec3f, Vec3f) {
+	return cameraRays(c, width, height)(x, y, 0.5, 0.5, nil)
+}
+
+// renderPasses adds passes samples to every pixel of state and writes their new
+// average into the frame buffer of image. opts must have its defaults set.
+//
+// The image is split into tiles of opts.tileSize pixels that the goroutines take from a shared queue, so
+// they write to disjoint parts of the frame buffer and need no locking while expensive regions are spread
+// between them. The jitter of each tile comes from its own random source seeded by opts.seed and the tile
+// index, so the output only depends on the seed and not on the number of threads. Each tile renders its
+// samples pass after pass, so that rendering the passes at once or one at a time gives the same image.
+func renderPasses(image Image, camera Camera, scene Scene, opts RenderOptions, state *frameState, passes int) {
+	pixelRay := cameraRays(camera, image.width, image.height)
+	// Les tests d'intersection de la profondeur ne comptent pas dans les statistiques,
+	// qui restent les mêmes avec ou sans tampon de profondeur
+	depthScene := scene
+	depthScene.counters = nil
+
+	// Progression : les workers incrémentent le compteur de pixels sous le verrou,
+	// ce qui garantit des appels sérialisés et des fractions croissantes
+	var progressMutex sync.Mutex
+	pixelsDone := 0
+	tileDone := func(t tile) {
+		if opts.progress == nil {
+			return
 		}
+		progressMutex.Lock()
+		defer progressMutex.Unlock()
+		pixelsDone += t.area()
+		opts.progress(float32(pixelsDone) / float32(state.region.area()))
+	}
+
+	queue := make(chan int, len(state.tiles))
+	for i := range state.tiles {
+		queue <- i
 	}
+	close(queue)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < opts.threads; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				t, rng := state.tiles[i], state.rngs[i]
+				primaryRays := 0
+				for pass := 0; pass < passes; pass++ {
+					for y := t.y0; y < t.y1; y++ {
+						for x := t.x0; x < t.x1; x++ {
+							idx := y*image.width + x
+							if image.depthBuffer != nil && state.accumulators[idx].count == 0 {
+								// Profondeur du rayon passant par le centre du pixel et de la lentille
+								ro, rd := pixelRay(x, y, 0.5, 0.5, nil)
+								depth := float32(math.Inf(1))
+								if nearest, dist := depthScene.nearest(ro, rd); nearest != nil {
+									depth = dist
+								}
+								image.depthBuffer[idx] = depth
+							}
+
+							ctx := opts.primaryContext(rng)
+							if state.rays != nil {
+								// Budget épuisé : le pixel garde la moyenne des échantillons déjà tracés
+								remaining := opts.maxRaysPerPixel - state.rays[idx]
+								if remaining <= 0 {
+									continue
+								}
+								ctx.budget = &remaining
+							}
 
+							dx, dy := float32(0.5), float32(0.5)
+							if state.jitter {
+								dx, dy = stratifiedOffset(state.accumulators[idx].count, state.samples, rng)
+							}
+							ro, rd := pixelRay(x, y, dx, dy, rng)
+							state.accumulators[idx].add(renderPixel(scene, ro, rd, ctx))
+							primaryRays++
+							if ctx.budget != nil {
+								state.rays[idx] = opts.maxRaysPerPixel - *ctx.budget
+							}
+						}
+					}
+				}
+				for y := t.y0; y < t.y1; y++ {
+					for x := t.x0; x < t.x1; x++ {
+						image.frameBuffer[y*image.width+x] = state.accumulators[y*image.width+x].mean()
+					}
+				}
+				scene.counters.addPrimaryRays(primaryRays)
+				tileDone(t)
+			}
+		}()
+	}
+	wg.Wait()
 }
 
 func populateScene(scene *Scene) {
+	scene.setAmbient(Vec3f{0.1, 0.1, 0.1})
+	scene.setSkyGradient(Vec3f{1, 1, 1}, Vec3f{0.5, 0.7, 1})
 
 	//Intégrer dans l'objet Scène
 	// scene.addElement(Sphere{1, Vec3f{0, 0, 8}, Lambert{Vec3f{1.0, 0, 0}}})
@@ -237,43 +1287,204 @@ func populateScene(scene *Scene) {
 	// scene.addElement(Sphere{0.5, Vec3f{-2, -2, 5}, Lambert{Vec3f{1.0, 1.0, 1.0}}})
 
 	scene.addElement(Sphere{1, Vec3f{0, 0, 8}, Phong{
-		scene.ambiantLight,
+		Vec3f{1, 0, 0},
 		Vec3f{1, 0, 0},
 		Vec3f{1, 1, 1},
 		3,
 	}})
 
-	scene.addLight(Light{Vec3f{1.0, 1.0, 1.0}, Vec3f{0, 10, 5}})
+	scene.addLight(Light{color: Vec3f{1.0, 1.0, 1.0}, position: Vec3f{0, 10, 5}})
 }
 
-func main() {
-	var cpuprofile = flag.String("cpuprofile", "", "write cpu profile to file")
-	flag.Parse()
+// renderCommand runs the render subcommand: it renders the built-in scene or a scene
+// file as set by the flags of args, and saves the image or the frames of an animation.
+func renderCommand(args []string, stderr io.Writer) error {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var cpuprofile = fs.String("cpuprofile", "", "write cpu profile to file")
+	var envFile = fs.String("env", "", "equirectangular PNG or JPEG image surrounding the scene, replacing its background")
+	var sceneFile = fs.String("scene", "", "JSON scene file to render instead of the built-in scene (its camera replaces the camera flags)")
+	var threads = fs.Int("threads", runtime.NumCPU(), "number of goroutines used for rendering")
+	var samples = fs.Int("samples", 1, "number of rays per pixel (anti-aliasing)")
+	var depth = fs.Int("depth", defaultMaxDepth, "maximum number of bounces of reflected and refracted rays")
+	var maxRays = fs.Int("max-rays", 0, "maximum number of rays traced per pixel, shadow and ambient occlusion rays included, 0 for no limit")
+	var epsilon = fs.Float64("epsilon", surfaceEpsilon, "offset of the rays leaving a surface, avoiding shadow acne; negative for none")
+	var roulette = fs.Bool("roulette", false, "use Russian roulette instead of stopping the rays deeper than -depth")
+	var gamma = fs.Float64("gamma", 2.2, "gamma used to encode the image, 1 to disable correction")
+	var depthOut = fs.String("depth-out", "", "also write the depth buffer as a grayscale PNG to this path, numbered like the frames of an animation")
+	var envLighting = fs.Bool("env-lighting", false, "light the surfaces with the environment given by -env instead of the ambient light")
+	var accel = fs.String("accel", "bvh", "acceleration structure of the intersection tests: bvh, grid or none")
+	var debug = fs.String("debug", "none", "debug mode replacing the materials: none, normals, depth or lights")
+	var tonemap = fs.String("tonemap", "none", "tone mapping operator applied before gamma: none, reinhard or aces")
+	var width = fs.Int("width", 4096, "width of the rendered image in pixels")
+	var height = fs.Int("height", 4096, "height of the rendered image in pixels")
+	var out = fs.String("out", "./result.png", "path of the rendered image (.png, .jpg, .jpeg or .ppm), whose extension also sets the format of the frames of an animation")
+	var frames = fs.Int("frames", 0, "number of frames of a turntable animation, 0 to render a single image")
+	var outDir = fs.String("out-dir", "./frames", "directory where the frames of the animation are written")
+	var quality = fs.Int("quality", defaultJPEGQuality, "quality of JPEG output, from 1 to 100")
+	var fov = fs.Float64("fov", defaultFovY, "vertical field of view of the camera, in degrees")
+	var aperture = fs.Float64("aperture", 0, "diameter of the camera lens, 0 to disable depth of field")
+	var focus = fs.Float64("focus", 13, "distance from the camera to the plane in focus")
+	var seed = fs.Int64("seed", 0, "seed of the random sampling, the same seed giving the same image")
+	var tileSize = fs.Int("tile", defaultTileSize, "size in pixels of the square tiles shared between the rendering goroutines")
+	var progress = fs.Bool("progress", false, "print the progress of the render")
+	var supersample = fs.Int("supersample", 1, "render at this many times the resolution and average blocks of pixels down to it")
+	var showStats = fs.Bool("stats", false, "print the duration of the render and the number of rays cast")
+	var ao = fs.Bool("ao", false, "enable ambient occlusion")
+	var aoSamples = fs.Int("ao-samples", 16, "number of rays cast per point for ambient occlusion")
+	var ortho = fs.Bool("ortho", false, "use an orthographic projection instead of a perspective one")
+	var orthoScale = fs.Float64("ortho-scale", 4, "height of the area seen by the orthographic camera, in world units")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if *width <= 0 || *height <= 0 {
+		return fmt.Errorf("invalid image size %dx%d: width and height must be positive", *width, *height)
+	}
+	if *supersample < 1 {
+		return fmt.Errorf("invalid supersampling factor %d: must be at least 1", *supersample)
+	}
+	if _, err := outputFormat(*out); err != nil {
+		return err
+	}
+	if _, err := toneMapOperator(*tonemap); err != nil {
+		return err
+	}
+	if err := checkDebugMode(*debug); err != nil {
+		return err
+	}
+	if *accel != "bvh" && *accel != "grid" && *accel != "none" {
+		return fmt.Errorf("unknown acceleration structure %q: expected bvh, grid or none", *accel)
+	}
 
 	if *cpuprofile != "" {
 		f, err := os.Create(*cpuprofile)
 		if err != nil {
-			log.Fatal(err)
+			return err
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return err
 		}
-		pprof.StartCPUProfile(f)
 		defer pprof.StopCPUProfile()
 	}
 
-	width := 4096
-	height := 4096
 	//Créer un objet Scène
 	scene := Scene{}
+	//Créer une caméra
+	camera, err := NewCamera(Vec3f{0, 0, -5}, Vec3f{0, 0, 5}, Vec3f{0, 1, 0}, float32(*fov))
+	if err != nil {
+		return err
+	}
+	camera.aperture = float32(*aperture)
+	camera.focusDistance = float32(*focus)
+	camera.orthographic = *ortho
+	camera.orthoScale = float32(*orthoScale)
 
 	//Initialiser la scène
-	populateScene(&scene)
-	//Créer une caméra
-	camera := Camera{Vec3f{0, 0, -5}, Vec3f{0, 1, 0}, Vec3f{0, 0, 5}}
+	if *sceneFile != "" {
+		scene, camera, err = LoadScene(*sceneFile)
+		if err != nil {
+			return err
+		}
+	} else {
+		populateScene(&scene)
+	}
+	if *envFile != "" {
+		env, err := LoadEnvironment(*envFile)
+		if err != nil {
+			return err
+		}
+		scene.setEnvironment(env)
+		scene.setEnvironmentLighting(*envLighting)
+	}
+	if *ao {
+		scene.setAmbientOcclusion(*aoSamples, defaultAORadius)
+	}
+	switch *accel {
+	case "bvh":
+		scene.buildBVH()
+	case "grid":
+		scene.buildGrid()
+	}
 
-	image := Image{make([]rgbRepresentation, width*height), width, height}
 	//fonction de rendu
-	renderFrame(image, camera, scene)
-	//Sauvegarde de l'image
-	if err := image.save("./result.png"); err != nil {
-		panic(err)
+	opts := RenderOptions{
+		samples:         *samples,
+		maxDepth:        *depth,
+		maxRaysPerPixel: *maxRays,
+		threads:         *threads,
+		tileSize:        *tileSize,
+		seed:            *seed,
+		roulette:        *roulette,
+		epsilon:         float32(*epsilon),
+	}
+	if *debug != "none" {
+		opts.debug = *debug
+	}
+	opts.depth = *depthOut != ""
+	if *progress {
+		opts.progress = func(fraction float32) {
+			fmt.Fprintf(stderr, "\rrendering: %3.0f%%", fraction*100)
+			if fraction >= 1 {
+				fmt.Fprintln(stderr)
+			}
+		}
+	}
+	develop := func(image Image) error {
+		if err := image.toneMap(*tonemap); err != nil {
+			return err
+		}
+		image.applyGamma(float32(*gamma))
+		return nil
 	}
+
+	// Réduit, développe et sauvegarde une image rendue, avec sa profondeur si demandée
+	finish := func(image Image, stats RenderStats, path, depthPath string) error {
+		image = image.downsample(*supersample)
+		if *showStats {
+			fmt.Fprintln(stderr, stats)
+		}
+		if err := develop(image); err != nil {
+			return err
+		}
+		if err := image.saveAs(path, *quality); err != nil {
+			return err
+		}
+		if depthPath != "" {
+			return image.saveDepthPNG(depthPath)
+		}
+		return nil
+	}
+
+	if *frames > 0 {
+		// Animation : la caméra fait un tour complet autour de sa cible ; les images ont
+		// le format de -out et les profondeurs sont numérotées comme elles
+		if err := os.MkdirAll(*outDir, 0755); err != nil {
+			return err
+		}
+		frameScene := func(frame int) (Scene, Camera) {
+			return scene, turntable(camera, 2*math.Pi*float64(frame)/float64(*frames))
+		}
+		save := func(frame int, image Image, stats RenderStats) error {
+			path := framePath(filepath.Join(*outDir, "frame"+filepath.Ext(*out)), frame)
+			depthPath := ""
+			if *depthOut != "" {
+				depthPath = framePath(*depthOut, frame)
+			}
+			return finish(image, stats, path, depthPath)
+		}
+		return renderAnimation(*frames, frameScene, *width**supersample, *height**supersample, opts, save)
+	}
+
+	image, stats, err := scene.Render(camera, *width**supersample, *height**supersample, opts)
+	if err != nil {
+		return err
+	}
+	//Sauvegarde de l'image
+	return finish(image, stats, *out, *depthOut)
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
 }
@@ -0,0 +1,765 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestImageSaveChannels(t *testing.T) {
+	img := Image{
+		frameBuffer: []Vec3f{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}, {1, 1, 1}},
+		width:       2,
+		height:      2,
+	}
+	path := filepath.Join(t.TempDir(), "pattern.png")
+	if err := img.save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	decoded, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []color.RGBA{
+		{255, 0, 0, 255}, {0, 255, 0, 255},
+		{0, 0, 255, 255}, {255, 255, 255, 255},
+	}
+	for i, w := range want {
+		x, y := i%2, i/2
+		if got := color.RGBAModel.Convert(decoded.At(x, y)).(color.RGBA); got != w {
+			t.Errorf("pixel (%d, %d) = %v, want %v", x, y, got, w)
+		}
+	}
+}
+
+func TestSphereIntersectionDistance(t *testing.T) {
+	tests := []struct {
+		name   string
+		sphere Sphere
+		ro, rd Vec3f
+		hit    bool
+		t      float32
+	}{
+		{"unit sphere ahead", Sphere{1, Vec3f{0, 0, 5}, nil}, Vec3f{}, Vec3f{0, 0, 1}, true, 4},
+		{"large sphere ahead", Sphere{3, Vec3f{0, 0, 10}, nil}, Vec3f{}, Vec3f{0, 0, 1}, true, 7},
+		{"unnormalized direction", Sphere{1, Vec3f{0, 0, 5}, nil}, Vec3f{}, Vec3f{0, 0, 2}, true, 2},
+		{"off-axis chord", Sphere{2, Vec3f{1, 0, 6}, nil}, Vec3f{1, 1, 0}, Vec3f{0, 0, 1}, true, 6 - float32(math.Sqrt(3))},
+		{"origin inside", Sphere{2, Vec3f{0, 0, 0}, nil}, Vec3f{}, Vec3f{1, 0, 0}, true, 2},
+		{"sphere behind", Sphere{1, Vec3f{0, 0, -5}, nil}, Vec3f{}, Vec3f{0, 0, 1}, false, 0},
+		{"miss beside", Sphere{1, Vec3f{3, 0, 5}, nil}, Vec3f{}, Vec3f{0, 0, 1}, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit, dist := tt.sphere.isIntersectedByRay(tt.ro, tt.rd)
+			if hit != tt.hit {
+				t.Fatalf("hit = %v, want %v", hit, tt.hit)
+			}
+			if hit && !approx(dist, tt.t, 1e-5) {
+				t.Errorf("t = %v, want %v", dist, tt.t)
+			}
+		})
+	}
+}
+
+func TestSphereNormalAtTop(t *testing.T) {
+	s := Sphere{1, Vec3f{}, nil}
+	// Rayon vertical descendant, qui touche le pôle nord
+	ro, rd := Vec3f{0, 5, 0}, Vec3f{0, -1, 0}
+	ok, dist := s.isIntersectedByRay(ro, rd)
+	if !ok {
+		t.Fatal("ray misses the sphere")
+	}
+	n, _ := s.surface(Add(ro, rd.mul(dist)), rd)
+	if !approxVec(n, Vec3f{0, 1, 0}, 1e-6) {
+		t.Errorf("normal = %v, want (0, 1, 0)", n)
+	}
+}
+
+// testContext returns the context of a primary ray with the default render options.
+func testContext() rayContext {
+	return RenderOptions{}.withDefaults().primaryContext(rand.New(rand.NewSource(1)))
+}
+
+// shade returns the color given by its material to the nearest hit of the ray.
+func shade(t *testing.T, scene Scene, ro, rd Vec3f) Vec3f {
+	t.Helper()
+	hit, ok := scene.intersect(ro, rd)
+	if !ok {
+		t.Fatalf("ray (%v, %v) hits nothing", ro, rd)
+	}
+	return hit.material.render(rd, hit, scene, testContext())
+}
+
+func TestShadowRemovesLightContribution(t *testing.T) {
+	floor := Phong{Vec3f{1, 1, 1}, Vec3f{0.5, 0.5, 0.5}, Vec3f{0.5, 0.5, 0.5}, 10}
+	scene := Scene{}
+	scene.setAmbient(Vec3f{0.1, 0.1, 0.1})
+	scene.addElement(Plane{point: Vec3f{}, normal: Vec3f{0, 1, 0}, Material: floor})
+	scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{0, 10, 0}})
+	down := Vec3f{0, -1, 0}
+
+	lit := shade(t, scene, Vec3f{0, 1, 0}, down)
+	// Petite sphère entre le sol et la lumière
+	scene.addElement(Sphere{1, Vec3f{0, 5, 0}, Lambert{Vec3f{1, 1, 1}}})
+	shadowed := shade(t, scene, Vec3f{0, 1, 0}, down)
+	outside := shade(t, scene, Vec3f{5, 1, 0}, down)
+
+	ambient := Vec3f{0.1, 0.1, 0.1}
+	if !approxVec(shadowed, ambient, 1e-6) {
+		t.Errorf("shadowed point = %v, want the ambient term %v only", shadowed, ambient)
+	}
+	if lit.x <= ambient.x || outside.x <= ambient.x {
+		t.Errorf("lit points = %v and %v, want brighter than the ambient term %v", lit, outside, ambient)
+	}
+	if !scene.isOccluded(Vec3f{0, 1e-3, 0}, Vec3f{0, 10, 0}) {
+		t.Error("isOccluded = false under the sphere")
+	}
+}
+
+func TestTwoLightsBrighterThanOne(t *testing.T) {
+	materials := map[string]Materials{
+		"lambert": Lambert{Vec3f{1, 1, 1}},
+		"phong":   Phong{Vec3f{0.1, 0.1, 0.1}, Vec3f{0.3, 0.3, 0.3}, Vec3f{0.2, 0.2, 0.2}, 10},
+	}
+	for name, m := range materials {
+		t.Run(name, func(t *testing.T) {
+			scene := Scene{}
+			scene.setAmbient(Vec3f{0.05, 0.05, 0.05})
+			scene.addElement(Plane{point: Vec3f{}, normal: Vec3f{0, 1, 0}, Material: m})
+			scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{-5, 5, 0}})
+			ro, rd := Vec3f{0, 2, -2}, Vec3f{0, -1, 1}.normalized()
+			one := shade(t, scene, ro, rd)
+
+			scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{5, 5, 0}})
+			two := shade(t, scene, ro, rd)
+			if two.x <= one.x || two.y <= one.y || two.z <= one.z {
+				t.Errorf("two lights = %v, want brighter than one light = %v", two, one)
+			}
+		})
+	}
+}
+
+// benchmarkScene returns a small scene with a few spheres over a floor, lit by a light.
+func benchmarkScene() (Scene, Camera) {
+	scene := Scene{}
+	scene.setAmbient(Vec3f{0.1, 0.1, 0.1})
+	scene.addElement(Plane{point: Vec3f{0, -1, 0}, normal: Vec3f{0, 1, 0}, Material: Lambert{Vec3f{0.8, 0.8, 0.8}}})
+	for i := 0; i < 5; i++ {
+		scene.addElement(Sphere{0.8, Vec3f{float32(i)*2 - 4, 0, 8}, Phong{
+			Vec3f{0.1, 0, 0}, Vec3f{0.7, 0.2, 0.2}, Vec3f{1, 1, 1}, 20,
+		}})
+	}
+	scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{0, 10, 0}})
+	camera, _ := NewCamera(Vec3f{0, 1, -2}, Vec3f{0, 0, 8}, Vec3f{0, 1, 0}, 60)
+	return scene, camera
+}
+
+func BenchmarkRenderFrame(b *testing.B) {
+	scene, camera := benchmarkScene()
+	for _, bench := range []struct {
+		name    string
+		threads int
+	}{
+		{"serial", 1},
+		{"parallel", runtime.NumCPU()},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			img := Image{frameBuffer: make([]Vec3f, 128*128), width: 128, height: 128}
+			opts := RenderOptions{threads: bench.threads}
+			for i := 0; i < b.N; i++ {
+				renderFrame(img, camera, scene, opts, tile{0, 0, 128, 128})
+			}
+		})
+	}
+}
+
+func TestSupersamplingHalfCoveredPixel(t *testing.T) {
+	// Une seule image d'un pixel dont la moitié gauche voit un mur blanc et l'autre le fond noir
+	scene := Scene{}
+	scene.addElement(Quad{corner: Vec3f{-10, -10, 5}, u: Vec3f{10, 0, 0}, v: Vec3f{0, 20, 0}, Material: Emissive{Vec3f{1, 1, 1}, 1}})
+	camera, _ := NewCamera(Vec3f{}, Vec3f{0, 0, 1}, Vec3f{0, 1, 0}, 60)
+	camera.orthographic = true
+	camera.orthoScale = 1
+
+	// 2 × 2 échantillons stratifiés : deux de chaque côté du bord du mur
+	img := Image{frameBuffer: make([]Vec3f, 1), width: 1, height: 1}
+	renderFrame(img, camera, scene, RenderOptions{samples: 4}, tile{0, 0, 1, 1})
+	if got := img.frameBuffer[0]; !approxVec(got, Vec3f{0.5, 0.5, 0.5}, 1e-6) {
+		t.Errorf("pixel = %v, want the intermediate gray 0.5", got)
+	}
+}
+
+func TestGammaMidGray(t *testing.T) {
+	img := Image{frameBuffer: []Vec3f{{0.5, 0.5, 0.5}}, width: 1, height: 1}
+	img.applyGamma(2.2)
+	want := math.Pow(0.5, 1/2.2) * 255
+	got := img.toRGBA().RGBAAt(0, 0)
+	for _, c := range []uint8{got.R, got.G, got.B} {
+		if math.Abs(float64(c)-want) > 0.5 {
+			t.Errorf("encoded mid-gray = %v, want %.2f within rounding", got, want)
+		}
+	}
+}
+
+func TestSaveJPEGRoundTrip(t *testing.T) {
+	colors := []Vec3f{{0.8, 0.2, 0.1}, {0.1, 0.6, 0.3}, {0.2, 0.3, 0.9}, {0.5, 0.5, 0.5}}
+	// Blocs de couleur unie de 8 × 8 pixels, que la compression JPEG altère peu
+	img := Image{frameBuffer: make([]Vec3f, 16*16), width: 16, height: 16}
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			img.frameBuffer[y*16+x] = colors[(y/8)*2+x/8]
+		}
+	}
+	path := filepath.Join(t.TempDir(), "blocks.jpg")
+	if err := img.saveJPEG(path, 95); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	decoded, err := jpeg.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range colors {
+		x, y := (i%2)*8+4, (i/2)*8+4
+		want := clampColor(c)
+		got := color.RGBAModel.Convert(decoded.At(x, y)).(color.RGBA)
+		for _, d := range []int{int(got.R) - int(want.r), int(got.G) - int(want.g), int(got.B) - int(want.b)} {
+			if d < -8 || d > 8 {
+				t.Errorf("pixel (%d, %d) = %v, want about %v", x, y, got, want)
+				break
+			}
+		}
+	}
+}
+
+func TestSkyGradientEndpoints(t *testing.T) {
+	scene := Scene{}
+	bottom, top := Vec3f{1, 1, 1}, Vec3f{0.5, 0.7, 1}
+	scene.setSkyGradient(bottom, top)
+	if got := renderPixel(scene, Vec3f{}, Vec3f{0, 1, 0}, testContext()); !approxVec(got, top, 1e-6) {
+		t.Errorf("ray pointing up = %v, want the top color %v", got, top)
+	}
+	if got := renderPixel(scene, Vec3f{}, Vec3f{0, -1, 0}, testContext()); !approxVec(got, bottom, 1e-6) {
+		t.Errorf("ray pointing down = %v, want the bottom color %v", got, bottom)
+	}
+}
+
+func TestOrthographicRays(t *testing.T) {
+	camera, _ := NewCamera(Vec3f{0, 0, -5}, Vec3f{}, Vec3f{0, 1, 0}, 60)
+	camera.orthographic = true
+	camera.orthoScale = 4
+	ro1, rd1 := camera.ray(10, 20, 100, 100)
+	ro2, rd2 := camera.ray(80, 70, 100, 100)
+	if rd1 != rd2 {
+		t.Errorf("directions = %v and %v, want identical", rd1, rd2)
+	}
+	if !approxVec(rd1, Vec3f{0, 0, 1}, 1e-6) {
+		t.Errorf("direction = %v, want the view direction (0, 0, 1)", rd1)
+	}
+	if ro1 == ro2 {
+		t.Errorf("origins are both %v, want different", ro1)
+	}
+}
+
+func TestWiderFovWidensCornerRay(t *testing.T) {
+	cornerAngle := func(fov float32) float32 {
+		camera, _ := NewCamera(Vec3f{}, Vec3f{0, 0, 1}, Vec3f{0, 1, 0}, fov)
+		_, rd := camera.ray(0, 0, 64, 48)
+		return angleBetween(rd, camera.direction())
+	}
+	narrow, wide := cornerAngle(30), cornerAngle(90)
+	if wide <= narrow {
+		t.Errorf("corner ray angle = %v at 90°, want wider than %v at 30°", wide, narrow)
+	}
+}
+
+func TestThinLensRaysConverge(t *testing.T) {
+	camera, _ := NewCamera(Vec3f{}, Vec3f{0, 0, 1}, Vec3f{0, 1, 0}, 40)
+	camera.aperture = 0.5
+	camera.focusDistance = 10
+	pixelRay := cameraRays(camera, 64, 64)
+	rng := rand.New(rand.NewSource(1))
+
+	var focus Vec3f
+	origins := map[Vec3f]bool{}
+	for i := 0; i < 16; i++ {
+		ro, rd := pixelRay(20, 30, 0.5, 0.5, rng)
+		origins[ro] = true
+		// Point où le rayon traverse le plan focal z = 10
+		p := Add(ro, rd.mul((camera.focusDistance-ro.z)/rd.z))
+		if i == 0 {
+			focus = p
+		} else if !approxVec(p, focus, 1e-4) {
+			t.Errorf("ray %d crosses the focal plane at %v, want %v", i, p, focus)
+		}
+	}
+	if len(origins) < 16 {
+		t.Errorf("got %d distinct origins out of 16 rays, want them spread over the lens", len(origins))
+	}
+}
+
+func TestToneMapBrightInput(t *testing.T) {
+	for _, mode := range []string{"reinhard", "aces"} {
+		img := Image{frameBuffer: []Vec3f{{50, 1000, 4}}, width: 1, height: 1}
+		if err := img.toneMap(mode); err != nil {
+			t.Fatal(err)
+		}
+		c := img.frameBuffer[0]
+		for _, v := range []float32{c.x, c.y, c.z} {
+			if v < 0 || v > 1 {
+				t.Errorf("%s: bright input mapped to %v, want within [0, 1]", mode, c)
+				break
+			}
+		}
+	}
+
+	// Gris moyen photographique (18 %)
+	img := Image{frameBuffer: []Vec3f{{0.18, 0.18, 0.18}}, width: 1, height: 1}
+	if err := img.toneMap("reinhard"); err != nil {
+		t.Fatal(err)
+	}
+	if c := img.frameBuffer[0]; !approxVec(c, Vec3f{0.18, 0.18, 0.18}, 0.03) {
+		t.Errorf("reinhard: mid-gray mapped to %v, want about 0.18", c)
+	}
+}
+
+func TestAverageBeforeQuantizing(t *testing.T) {
+	samples := []float32{0.6 / 255, 0.6 / 255, 0.2 / 255}
+
+	// Moyenne des couleurs linéaires, quantifiée une seule fois
+	var acc Accumulator
+	for _, s := range samples {
+		acc.add(Vec3f{s, s, s})
+	}
+	floatFirst := clampColor(acc.mean()).r
+
+	// Moyenne des couleurs déjà quantifiées sur 8 bits
+	sum := 0.0
+	for _, s := range samples {
+		sum += float64(clampColor(Vec3f{s, s, s}).r)
+	}
+	quantizedFirst := uint8(math.Round(sum / float64(len(samples))))
+
+	if floatFirst != 0 {
+		t.Errorf("float average quantized to %d, want 0 (0.47/255)", floatFirst)
+	}
+	if quantizedFirst == floatFirst {
+		t.Errorf("averaging quantized samples gives %d too, want the rounding error to show", quantizedFirst)
+	}
+}
+
+func TestSavePPM(t *testing.T) {
+	img := Image{
+		frameBuffer: []Vec3f{{1, 0, 0}, {0, 0.5, 0}, {0, 0, 1}, {2, -1, 0.2}, {1, 1, 1}, {0, 0, 0}},
+		width:       3,
+		height:      2,
+	}
+	path := filepath.Join(t.TempDir(), "image.ppm")
+	if err := img.savePPM(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPPM(t, img, data)
+}
+
+// checkPPM checks that data is the binary PPM (P6) encoding of img.
+func checkPPM(t *testing.T, img Image, data []byte) {
+	t.Helper()
+	var magic string
+	var width, height, maxValue int
+	n, err := fmt.Sscanf(string(data), "%s\n%d %d\n%d\n", &magic, &width, &height, &maxValue)
+	if err != nil || n != 4 {
+		t.Fatalf("cannot parse the PPM header: %v", err)
+	}
+	if magic != "P6" || width != img.width || height != img.height || maxValue != 255 {
+		t.Fatalf("header = %s %d %d %d, want P6 %d %d 255", magic, width, height, maxValue, img.width, img.height)
+	}
+	header := fmt.Sprintf("P6\n%d %d\n255\n", width, height)
+	pixels := data[len(header):]
+	if len(pixels) != 3*len(img.frameBuffer) {
+		t.Fatalf("got %d bytes of pixels, want %d", len(pixels), 3*len(img.frameBuffer))
+	}
+	for i, v := range img.frameBuffer {
+		c := clampColor(v)
+		if got := [3]byte(pixels[3*i : 3*i+3]); got != [3]byte{c.r, c.g, c.b} {
+			t.Errorf("pixel %d = %v, want %v", i, got, c)
+		}
+	}
+}
+
+func TestFogDistance(t *testing.T) {
+	red, fogColor := Vec3f{1, 0, 0}, Vec3f{0.5, 0.5, 0.5}
+	scene := Scene{}
+	scene.addElement(Sphere{1, Vec3f{0, 0, 2}, Emissive{red, 1}})
+	scene.setFog(fogColor, 0.05)
+
+	// Touchée à une distance de 1 : 1 - exp(-0.05) ≈ 5 % de brouillard
+	near := renderPixel(scene, Vec3f{}, Vec3f{0, 0, 1}, testContext())
+	if !approxVec(near, red, 0.05) || near == red {
+		t.Errorf("near hit = %v, want slightly fogged red", near)
+	}
+	// Touchée à une distance de 99 : exp(-4.95) < 1 % de rouge restant
+	far := renderPixel(scene, Vec3f{0, 0, -98}, Vec3f{0, 0, 1}, testContext())
+	if !approxVec(far, fogColor, 0.01) {
+		t.Errorf("far hit = %v, want almost the fog color %v", far, fogColor)
+	}
+}
+
+func TestNewCameraBasis(t *testing.T) {
+	position, target := Vec3f{1, 2, 3}, Vec3f{-2, 0, 7}
+	camera, err := NewCamera(position, target, Vec3f{0, 1, 0}, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	basis := [3]Vec3f{camera.right, camera.vertical, camera.forward}
+	for i, u := range basis {
+		if !approx(u.norme(), 1, 1e-6) {
+			t.Errorf("basis vector %d = %v has length %v, want 1", i, u, u.norme())
+		}
+		for _, v := range basis[i+1:] {
+			if d := Dot(u, v); !approx(d, 0, 1e-6) {
+				t.Errorf("basis vectors %v and %v have a dot product of %v, want 0", u, v, d)
+			}
+		}
+	}
+	if want := Sub(target, position).normalized(); !approxVec(camera.forward, want, 1e-6) {
+		t.Errorf("forward = %v, want %v", camera.forward, want)
+	}
+	// Le haut de l'image reste du côté du vecteur up
+	if camera.vertical.y <= 0 {
+		t.Errorf("vertical = %v, want pointing up", camera.vertical)
+	}
+
+	if _, err := NewCamera(Vec3f{}, Vec3f{0, 1, 0}, Vec3f{0, 2, 0}, 50); err == nil {
+		t.Error("NewCamera with up parallel to the view direction succeeded, want an error")
+	}
+}
+
+func TestRemoveElement(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		build func(*Scene)
+	}{
+		{"linear", func(*Scene) {}},
+		{"bvh", (*Scene).buildBVH},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			scene := Scene{}
+			for _, x := range []float32{-3, 0, 3} {
+				scene.addElement(Sphere{1, Vec3f{x, 0, 5}, Lambert{Vec3f{1, 1, 1}}})
+			}
+			if !scene.removeElement(1) {
+				t.Fatal("removeElement(1) = false, want true")
+			}
+			if scene.removeElement(1) {
+				t.Error("removing the same element twice succeeded")
+			}
+			tc.build(&scene)
+
+			// Les sphères restantes gardent leur identifiant
+			for id, x := range map[int]float32{0: -3, 2: 3} {
+				hit := scene.nearestHit(Vec3f{x, 0, 0}, Vec3f{0, 0, 1})
+				if hit.object == nil || hit.id != id {
+					t.Errorf("ray towards x = %v hit %v (id %d), want the sphere %d", x, hit.object, hit.id, id)
+				}
+			}
+			if _, ok := scene.intersect(Vec3f{}, Vec3f{0, 0, 1}); ok {
+				t.Error("ray towards the removed sphere hit something")
+			}
+		})
+	}
+}
+
+func TestSphereHitRecord(t *testing.T) {
+	material := Lambert{Vec3f{1, 0, 0}}
+	scene := Scene{}
+	scene.addElement(Sphere{2, Vec3f{1, 0, 6}, material})
+
+	// Corde décalée de 1 de l'axe : touche en z = 6 - √3
+	hit, ok := scene.intersect(Vec3f{2, 0, 0}, Vec3f{0, 0, 1})
+	if !ok {
+		t.Fatal("ray misses the sphere")
+	}
+	sqrt3 := float32(math.Sqrt(3))
+	if want := (Vec3f{2, 0, 6 - sqrt3}); !approx(hit.t, 6-sqrt3, 1e-5) || !approxVec(hit.point, want, 1e-5) {
+		t.Errorf("hit at t = %v, point %v, want t = %v at %v", hit.t, hit.point, 6-sqrt3, want)
+	}
+	if want := (Vec3f{1, 0, -sqrt3}).mul(0.5); !approxVec(hit.normal, want, 1e-5) {
+		t.Errorf("normal = %v, want %v", hit.normal, want)
+	}
+	if hit.material != material {
+		t.Errorf("material = %v, want the sphere's %v", hit.material, material)
+	}
+}
+
+func TestRussianRouletteUnbiased(t *testing.T) {
+	// Deux miroirs partiels face à face, éclairés entre eux : chaque rebond ajoute
+	// 20 % de la couleur diffuse et renvoie 80 % du reste
+	mirror := Mirror{Vec3f{1, 1, 1}, 0.8}
+	scene := Scene{}
+	scene.addElement(Plane{point: Vec3f{}, normal: Vec3f{0, 1, 0}, Material: mirror})
+	scene.addElement(Plane{point: Vec3f{0, 1, 0}, normal: Vec3f{0, -1, 0}, Material: mirror})
+	scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{0, 0.5, 0}})
+	ro, rd := Vec3f{1, 0.5, 0}, Vec3f{0, -1, 0}
+
+	render := func(opts RenderOptions, samples int) float32 {
+		ctx := opts.withDefaults().primaryContext(rand.New(rand.NewSource(1)))
+		var acc Accumulator
+		for i := 0; i < samples; i++ {
+			acc.add(renderPixel(scene, ro, rd, ctx))
+		}
+		return acc.mean().x
+	}
+	reference := render(RenderOptions{maxDepth: rouletteDepthLimit}, 1)
+	shallow := render(RenderOptions{maxDepth: 2}, 1)
+	roulette := render(RenderOptions{maxDepth: 2, roulette: true}, 4000)
+
+	// Arrêtés à 3 rebonds, les rayons perdent 0.8³ ≈ 51 % de l'énergie
+	if shallow > 0.6*reference {
+		t.Errorf("fixed depth 2 gives %v, want much darker than the deep reference %v", shallow, reference)
+	}
+	if !approx(roulette, reference, 0.05*reference) {
+		t.Errorf("Russian roulette averages %v, want the deep reference %v", roulette, reference)
+	}
+}
+
+func TestSceneBounds(t *testing.T) {
+	scene := Scene{}
+	scene.addElement(Sphere{1, Vec3f{-2, 0, 5}, nil})
+	scene.addElement(Sphere{2, Vec3f{3, 1, -1}, nil})
+	// Le plan, non borné, n'agrandit pas la boîte
+	scene.addElement(Plane{point: Vec3f{0, -1, 0}, normal: Vec3f{0, 1, 0}})
+
+	want := AABB{min: Vec3f{-3, -1, -3}, max: Vec3f{5, 3, 6}}
+	if got := scene.bounds(); !approxVec(got.min, want.min, 1e-6) || !approxVec(got.max, want.max, 1e-6) {
+		t.Errorf("bounds = %v, want %v", got, want)
+	}
+}
+
+func TestLightBelowSurface(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		material Materials
+	}{
+		{"lambert", Lambert{Vec3f{1, 1, 1}}},
+		{"phong", Phong{Vec3f{}, Vec3f{1, 1, 1}, Vec3f{1, 1, 1}, 10}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// La lumière sous le sol ne doit ni l'éclairer ni l'assombrir
+			scene := Scene{}
+			scene.addElement(Plane{point: Vec3f{}, normal: Vec3f{0, 1, 0}, Material: tt.material})
+			scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{0, -5, 0}})
+			if got := shade(t, scene, Vec3f{0, 1, 0}, Vec3f{0, -1, 0}); got != (Vec3f{}) {
+				t.Errorf("color = %v, want black", got)
+			}
+		})
+	}
+}
+
+func TestDownsampleGradient(t *testing.T) {
+	// Dégradé horizontal en rouge et vertical en vert
+	img := Image{frameBuffer: make([]Vec3f, 16), width: 4, height: 4, depthBuffer: make([]float32, 16)}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			img.frameBuffer[y*4+x] = Vec3f{float32(x), float32(y), 0}
+			img.depthBuffer[y*4+x] = float32(10 - x - y)
+		}
+	}
+	small := img.downsample(2)
+	if small.width != 2 || small.height != 2 {
+		t.Fatalf("size = %dx%d, want 2x2", small.width, small.height)
+	}
+	want := []Vec3f{{0.5, 0.5, 0}, {2.5, 0.5, 0}, {0.5, 2.5, 0}, {2.5, 2.5, 0}}
+	wantDepth := []float32{8, 6, 6, 4}
+	for i := range want {
+		if small.frameBuffer[i] != want[i] {
+			t.Errorf("pixel %d = %v, want the block average %v", i, small.frameBuffer[i], want[i])
+		}
+		if small.depthBuffer[i] != wantDepth[i] {
+			t.Errorf("depth %d = %v, want the nearest depth of the block %v", i, small.depthBuffer[i], wantDepth[i])
+		}
+	}
+}
+
+func TestFacingMirrorsStopAtMaxDepth(t *testing.T) {
+	// Deux miroirs parfaits face à face, sans lumière : le rayon rebondit jusqu'à maxDepth
+	scene := Scene{}
+	scene.setBackground(Vec3f{1, 1, 1})
+	scene.addElement(Plane{point: Vec3f{}, normal: Vec3f{0, 1, 0}, Material: Mirror{Vec3f{}, 1}})
+	scene.addElement(Plane{point: Vec3f{0, 1, 0}, normal: Vec3f{0, -1, 0}, Material: Mirror{Vec3f{}, 1}})
+
+	for _, maxDepth := range []int{1, 5, 20} {
+		ctx := RenderOptions{maxDepth: maxDepth}.withDefaults().primaryContext(rand.New(rand.NewSource(1)))
+		budget := 1000
+		ctx.budget = &budget
+		if got := renderPixel(scene, Vec3f{0, 0.5, 0}, Vec3f{0, -1, 0}, ctx); got != (Vec3f{}) {
+			t.Errorf("maxDepth %d: color = %v, want black as the ray never escapes", maxDepth, got)
+		}
+		// Le rayon primaire puis un rayon réfléchi par profondeur autorisée
+		if traced := 1000 - budget; traced != maxDepth+1 {
+			t.Errorf("maxDepth %d: %d rays traced, want %d", maxDepth, traced, maxDepth+1)
+		}
+	}
+}
+
+func TestRayBudgetCountsShadowRays(t *testing.T) {
+	scene := Scene{}
+	scene.setAmbientOcclusion(8, 1)
+	scene.addElement(Plane{point: Vec3f{}, normal: Vec3f{0, 1, 0}, Material: Lambert{Vec3f{1, 1, 1}}})
+	scene.addLight(AreaLight{corner: Vec3f{-1, 5, -1}, u: Vec3f{2, 0, 0}, v: Vec3f{0, 0, 2}, color: Vec3f{1, 1, 1}, samples: 16})
+	scene.counters = &renderCounters{}
+
+	// 1 rayon primaire, puis 9 des 16 rayons d'ombre ; plus rien pour l'occlusion ambiante
+	ctx := testContext()
+	budget := 10
+	ctx.budget = &budget
+	renderPixel(scene, Vec3f{0, 1, 0}, Vec3f{0, -1, 0}, ctx)
+	if budget != 0 {
+		t.Errorf("budget left = %d, want 0", budget)
+	}
+	if got := scene.counters.shadowRays.Load(); got != 9 {
+		t.Errorf("shadow rays = %d, want the 9 left by the primary ray", got)
+	}
+
+	camera, _ := NewCamera(Vec3f{0, 1, -2}, Vec3f{}, Vec3f{0, 1, 0}, 60)
+	const width, height, maxRays = 4, 4, 10
+	render := func(maxRaysPerPixel int) RenderStats {
+		_, stats, err := scene.Render(camera, width, height, RenderOptions{samples: 4, maxRaysPerPixel: maxRaysPerPixel})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stats
+	}
+	if stats := render(0); stats.primaryRays+stats.shadowRays <= width*height*maxRays {
+		t.Fatalf("unlimited render traced %d rays, want more than the cap for the test to be meaningful", stats.primaryRays+stats.shadowRays)
+	}
+	if stats := render(maxRays); stats.primaryRays+stats.shadowRays > width*height*maxRays {
+		t.Errorf("render capped at %d rays per pixel traced %d primary and %d shadow rays, want at most %d",
+			maxRays, stats.primaryRays, stats.shadowRays, width*height*maxRays)
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	img := Image{
+		frameBuffer: []Vec3f{{1, 0, 0}, {0, 0.5, 0}, {0, 0, 1}, {2, -1, 0.2}, {1, 1, 1}, {0, 0, 0}},
+		width:       3,
+		height:      2,
+	}
+	var buf bytes.Buffer
+	if err := img.encode(&buf, "png"); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.Bounds(); got.Dx() != img.width || got.Dy() != img.height {
+		t.Fatalf("decoded size = %dx%d, want %dx%d", got.Dx(), got.Dy(), img.width, img.height)
+	}
+	// Le PNG est sans perte : chaque pixel redonne sa couleur quantifiée
+	for i, v := range img.frameBuffer {
+		c := clampColor(v)
+		want := color.RGBA{c.r, c.g, c.b, 255}
+		if got := color.RGBAModel.Convert(decoded.At(i%img.width, i/img.width)); got != want {
+			t.Errorf("pixel %d = %v, want %v", i, got, want)
+		}
+	}
+
+	buf.Reset()
+	if err := img.encode(&buf, "ppm"); err != nil {
+		t.Fatal(err)
+	}
+	checkPPM(t, img, buf.Bytes())
+}
+
+func TestSphereUV(t *testing.T) {
+	scene := Scene{}
+	scene.addElement(Sphere{1, Vec3f{}, Lambert{Vec3f{1, 1, 1}}})
+	// Touché en (0, 0, 1) : un quart de tour après +x, sur l'équateur
+	hit, ok := scene.intersect(Vec3f{0, 0, 5}, Vec3f{0, 0, -1})
+	if !ok {
+		t.Fatal("ray misses the sphere")
+	}
+	if want := (Vec2f{0.75, 0.5}); !hit.hasUV || !approx(hit.uv.x, want.x, 1e-6) || !approx(hit.uv.y, want.y, 1e-6) {
+		t.Errorf("uv = %v (hasUV %v), want %v", hit.uv, hit.hasUV, want)
+	}
+	if uv := (Sphere{1, Vec3f{}, nil}).uvAt(Vec3f{0, 1, 0}); !approx(uv.y, 0, 1e-6) {
+		t.Errorf("v at the north pole = %v, want 0", uv.y)
+	}
+}
+
+func TestDebugLightsCount(t *testing.T) {
+	scene := Scene{}
+	scene.addElement(Plane{point: Vec3f{}, normal: Vec3f{0, 1, 0}, Material: Lambert{Vec3f{1, 1, 1}}})
+	scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{-5, 5, 0}})
+	scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{5, 5, 0}})
+	// La sphère masque la seconde lumière à l'origine, mais pas en (0, 0, 3)
+	scene.addElement(Sphere{0.5, Vec3f{2.5, 2.5, 0}, Lambert{Vec3f{1, 1, 1}}})
+
+	ctx := testContext()
+	ctx.debug = "lights"
+	for _, tt := range []struct {
+		point Vec3f
+		count int
+	}{
+		{Vec3f{0, 0, 0}, 1},
+		{Vec3f{0, 0, 3}, 2},
+	} {
+		got := renderPixel(scene, Add(tt.point, Vec3f{0, 1, 0}), Vec3f{0, -1, 0}, ctx)
+		if want := lightCountRamp[tt.count]; got != want {
+			t.Errorf("point %v = %v, want the color of %d lights %v", tt.point, got, tt.count, want)
+		}
+	}
+}
+
+func TestCameraRaySymmetric(t *testing.T) {
+	camera, _ := NewCamera(Vec3f{1, 2, 3}, Vec3f{4, 2, 7}, Vec3f{0, 1, 0}, 50)
+	// Dimensions impaires : le pixel central est centré sur l'axe de la caméra
+	const width, height = 65, 49
+
+	ro, rd := camera.ray(width/2, height/2, width, height)
+	if ro != camera.position || !approxVec(rd, camera.forward, 1e-6) {
+		t.Errorf("center ray = (%v, %v), want (%v, %v)", ro, rd, camera.position, camera.forward)
+	}
+
+	// Les rayons des quatre pixels des coins font le même angle avec l'axe, de part et d'autre
+	_, topLeft := camera.ray(0, 0, width, height)
+	_, topRight := camera.ray(width-1, 0, width, height)
+	_, bottomLeft := camera.ray(0, height-1, width, height)
+	_, bottomRight := camera.ray(width-1, height-1, width, height)
+	angle := angleBetween(topLeft, camera.forward)
+	for _, corner := range []Vec3f{topRight, bottomLeft, bottomRight} {
+		if a := angleBetween(corner, camera.forward); !approx(a, angle, 1e-5) {
+			t.Errorf("corner ray %v makes an angle of %v with the view direction, want %v", corner, a, angle)
+		}
+	}
+	if Dot(Sub(topRight, topLeft), camera.right) <= 0 {
+		t.Error("right corner is not on the right of the left one")
+	}
+	if Dot(Sub(topLeft, bottomLeft), camera.vertical) <= 0 {
+		t.Error("top corner is not above the bottom one")
+	}
+}
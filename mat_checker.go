@@ -0,0 +1,26 @@
+package main
+
+import "math"
+
+// Checker is a procedural material alternating between two colors in a 3D
+// checkerboard pattern, the cells being 1/scale wide. Each cell is shaded as a
+// Lambertian surface of its color.
+type Checker struct {
+	even, odd Vec3f
+	scale     float32
+}
+
+// colorAt returns the color of the cell containing the point p.
+func (c Checker) colorAt(p Vec3f) Vec3f {
+	parity := math.Floor(float64(p.x*c.scale)) + math.Floor(float64(p.y*c.scale)) + math.Floor(float64(p.z*c.scale))
+	if int(parity)%2 == 0 {
+		return c.even
+	}
+	return c.odd
+}
+
+func (c Checker) render(rdi Vec3f, hit Hit, scene Scene, ctx rayContext) Vec3f {
+	omega, n := hit.point, hit.normal
+	// Léger décalage vers l'intérieur pour ne pas dépendre des erreurs d'arrondi sur les faces alignées
+	return Lambert{c.colorAt(Sub(omega, n.mul(surfaceEpsilon)))}.render(rdi, hit, scene, ctx)
+}
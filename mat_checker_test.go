@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestCheckerAdjacentCells(t *testing.T) {
+	white, black := Vec3f{1, 1, 1}, Vec3f{0.1, 0.1, 0.1}
+	scene := Scene{}
+	scene.addElement(Plane{point: Vec3f{}, normal: Vec3f{0, 1, 0}, Material: Checker{white, black, 1}})
+	scene.addLight(DirectionalLight{direction: Vec3f{0, -1, 0}, color: Vec3f{1, 1, 1}})
+
+	// Centres de deux cellules voisines le long de x, puis de z
+	down := Vec3f{0, -1, 0}
+	a := shade(t, scene, Vec3f{0.5, 1, 0.5}, down)
+	b := shade(t, scene, Vec3f{1.5, 1, 0.5}, down)
+	c := shade(t, scene, Vec3f{0.5, 1, 1.5}, down)
+	if a == b || a == c {
+		t.Errorf("adjacent cells = %v, %v and %v, want the first one different from its neighbours", a, b, c)
+	}
+	if b != c {
+		t.Errorf("diagonal cells = %v and %v, want the same color", b, c)
+	}
+	if got := (Checker{white, black, 1}).colorAt(Vec3f{0.5, -0.5, 0.5}); got != black {
+		t.Errorf("colorAt just below the plane = %v, want %v", got, black)
+	}
+}
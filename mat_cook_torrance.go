@@ -0,0 +1,71 @@
+package main
+
+import "math"
+
+// CookTorrance is a physically based material using the Cook-Torrance microfacet
+// BRDF with the GGX normal distribution, the Smith geometry term and the Schlick
+// approximation of the Fresnel term. roughness and metallic range from 0 to 1.
+type CookTorrance struct {
+	albedo    Vec3f
+	roughness float32
+	metallic  float32
+}
+
+// cookTorranceMinAlpha is the smallest GGX alpha (squared roughness) used by
+// CookTorrance, so that a roughness of 0 gives a very sharp highlight instead of NaN.
+const cookTorranceMinAlpha = 1e-3
+
+func (c CookTorrance) render(rdi Vec3f, hit Hit, scene Scene, ctx rayContext) Vec3f {
+	omega := hit.point
+	n := hit.normal.normalized()
+	V := rdi.inverte().normalized()
+	NdotV := max(Dot(n, V), 0)
+
+	// Réflectance à incidence normale : 4% pour les diélectriques, la couleur pour les métaux
+	f0 := Add(Vec3f{0.04, 0.04, 0.04}.mul(1-c.metallic), c.albedo.mul(c.metallic))
+
+	// Une rugosité nulle donnerait 0/0 dans la distribution GGX face à la lumière :
+	// elle est bornée à une surface presque parfaitement lisse
+	a := max(c.roughness*c.roughness, cookTorranceMinAlpha)
+	a2 := a * a
+	k := (c.roughness + 1) * (c.roughness + 1) / 8
+	geometry := func(cosTheta float32) float32 {
+		return cosTheta / (cosTheta*(1-k) + k)
+	}
+
+	// Terme ambiant, atténué par l'occlusion ambiante comme la diffuse
+	ao := scene.ambientOcclusion(omega, n, ctx)
+	res := Mul(c.albedo, scene.ambientAt(n)).mul(ao)
+	for _, light := range scene.lights {
+		L, I, _ := light.illuminate(omega)
+		// Une lumière derrière la surface n'apporte rien : inutile de lancer ses rayons d'ombre
+		NdotL := max(Dot(n, L), 0)
+		if NdotL == 0 {
+			continue
+		}
+		visibility := scene.visibility(light, ctx.offset(omega, n), ctx)
+		if visibility == 0 {
+			continue
+		}
+		I = I.mul(visibility)
+
+		H := Add(L, V).normalized()
+		NdotH := max(Dot(n, H), 0)
+		HdotV := max(Dot(H, V), 0)
+
+		// Distribution GGX des micro-facettes
+		denom := NdotH*NdotH*(a2-1) + 1
+		D := a2 / (math.Pi * denom * denom)
+		// Masquage / ombrage de Smith
+		G := geometry(NdotV) * geometry(NdotL)
+		// Fresnel de Schlick
+		F := fresnelSchlick(HdotV, f0)
+
+		specular := F.mul(D * G / (4*NdotV*NdotL + 1e-4))
+		kd := Sub(Vec3f{1, 1, 1}, F).mul(1 - c.metallic)
+		diffuse := Mul(kd, c.albedo).mul(ao / math.Pi)
+
+		res = Add(res, Mul(Add(diffuse, specular), I).mul(NdotL))
+	}
+	return res
+}
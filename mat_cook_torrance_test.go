@@ -0,0 +1,57 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// cookTorranceAt returns the color of a metallic Cook-Torrance floor lit from straight
+// above, seen from angle degrees off the vertical (the mirror direction of the light).
+func cookTorranceAt(roughness float32, angle float64) Vec3f {
+	m := CookTorrance{Vec3f{1, 1, 1}, roughness, 1}
+	scene := Scene{}
+	scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{0, 10, 0}})
+	hit := Hit{point: Vec3f{}, normal: Vec3f{0, 1, 0}, material: m}
+	rad := angle * math.Pi / 180
+	viewer := Vec3f{float32(math.Sin(rad)), float32(math.Cos(rad)), 0}
+	return m.render(viewer.inverte(), hit, scene, testContext())
+}
+
+func TestCookTorranceHighlightWidth(t *testing.T) {
+	// Part du pic restant à 15° de la direction miroir
+	spread := func(roughness float32) float32 {
+		return cookTorranceAt(roughness, 15).x / cookTorranceAt(roughness, 0).x
+	}
+	low, high := spread(0.2), spread(0.7)
+	if low >= high {
+		t.Errorf("highlight kept %v of its peak at 15° with roughness 0.2, want less than %v with roughness 0.7", low, high)
+	}
+	if peakLow, peakHigh := cookTorranceAt(0.2, 0).x, cookTorranceAt(0.7, 0).x; peakLow <= peakHigh {
+		t.Errorf("peak = %v with roughness 0.2, want brighter than %v with roughness 0.7", peakLow, peakHigh)
+	}
+}
+
+func TestCookTorranceZeroRoughness(t *testing.T) {
+	for _, angle := range []float64{0, 10, 45} {
+		c := cookTorranceAt(0, angle)
+		for _, v := range []float32{c.x, c.y, c.z} {
+			if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+				t.Fatalf("color %v° off the mirror direction = %v, want finite", angle, c)
+			}
+		}
+	}
+}
+
+func TestCookTorranceLightBehindCastsNoShadowRay(t *testing.T) {
+	m := CookTorrance{Vec3f{1, 1, 1}, 0.5, 0}
+	scene := Scene{}
+	scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{0, -10, 0}})
+	scene.counters = &renderCounters{}
+	hit := Hit{point: Vec3f{}, normal: Vec3f{0, 1, 0}, material: m}
+	if c := m.render(Vec3f{0, -1, 0}, hit, scene, testContext()); c != (Vec3f{}) {
+		t.Errorf("color lit from below = %v, want black", c)
+	}
+	if got := scene.counters.shadowRays.Load(); got != 0 {
+		t.Errorf("shadow rays = %d towards a light behind the surface, want 0", got)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import "math"
+
+// Dielectric is a transparent material (glass, water...) parameterized by its index
+// of refraction. Rays going through it are bent following Snell's law and traced
+// again through the scene; on total internal reflection they are reflected instead.
+// Colored media absorb the light going through them following Beer's law: after a
+// distance d inside, each channel is scaled by exp(-absorption*d).
+type Dielectric struct {
+	ior        float32
+	absorption Vec3f
+}
+
+func (d Dielectric) render(rdi Vec3f, hit Hit, scene Scene, ctx rayContext) Vec3f {
+	omega, n := hit.point, hit.normal
+	i := rdi.normalized()
+
+	// Le rayon entre dans l'objet si il va à l'encontre de la normale, sinon il en sort
+	eta := 1 / d.ior
+	inside := Dot(i, n) > 0
+	if inside {
+		n = n.inverte()
+		eta = d.ior
+	}
+
+	var c Vec3f
+	if rd, ok := refract(i, n, eta); ok {
+		c = renderPixel(scene, ctx.offset(omega, n.inverte()), rd.normalized(), ctx.child())
+	} else {
+		// Réflexion totale interne
+		c = renderPixel(scene, ctx.offset(omega, n), reflect(i, n), ctx.child())
+	}
+	if inside {
+		// Le rayon vient de parcourir hit.t à l'intérieur du milieu
+		c = Mul(c, d.transmittance(hit.t*rdi.norme()))
+	}
+	return c
+}
+
+// transmittance returns the fraction of each channel of the light left after a
+// distance dist inside the medium.
+func (d Dielectric) transmittance(dist float32) Vec3f {
+	if d.absorption == (Vec3f{}) {
+		return Vec3f{1, 1, 1}
+	}
+	attenuate := func(a float32) float32 {
+		return float32(math.Exp(float64(-a * dist)))
+	}
+	return Vec3f{attenuate(d.absorption.x), attenuate(d.absorption.y), attenuate(d.absorption.z)}
+}
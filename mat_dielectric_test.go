@@ -0,0 +1,33 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDielectricBeerLaw(t *testing.T) {
+	glass := Dielectric{ior: 1.5, absorption: Vec3f{1, 0.5, 0}}
+	through := func(thickness float32) Vec3f {
+		// Plaque large traversée à incidence normale, devant un fond blanc
+		scene := Scene{}
+		scene.setBackground(Vec3f{1, 1, 1})
+		scene.addElement(NewBox(Vec3f{}, Vec3f{10, 10, thickness}, glass))
+		return renderPixel(scene, Vec3f{0, 0, -5}, Vec3f{0, 0, 1}, testContext())
+	}
+	thin, thick := through(0.5), through(2)
+	if thick.x >= thin.x || thick.y >= thin.y {
+		t.Errorf("light through 2 = %v, want darker than %v through 0.5", thick, thin)
+	}
+	for _, tt := range []struct {
+		thickness float32
+		got       Vec3f
+	}{
+		{0.5, thin},
+		{2, thick},
+	} {
+		want := Vec3f{float32(math.Exp(float64(-tt.thickness))), float32(math.Exp(float64(-0.5 * tt.thickness))), 1}
+		if !approxVec(tt.got, want, 1e-2) {
+			t.Errorf("light through %v = %v, want exp(-absorption*d) = %v", tt.thickness, tt.got, want)
+		}
+	}
+}
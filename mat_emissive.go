@@ -0,0 +1,12 @@
+package main
+
+// Emissive is a self-illuminated material: it renders its own color scaled by
+// intensity whatever the lights of the scene.
+type Emissive struct {
+	color     Vec3f
+	intensity float32
+}
+
+func (e Emissive) render(rdi Vec3f, hit Hit, scene Scene, ctx rayContext) Vec3f {
+	return e.color.mul(e.intensity)
+}
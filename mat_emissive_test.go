@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+func TestEmissiveWithoutLights(t *testing.T) {
+	emission := Vec3f{0.2, 0.6, 1}
+	scene := Scene{}
+	scene.addElement(Sphere{1, Vec3f{0, 0, 5}, Emissive{emission, 0.5}})
+	camera, _ := NewCamera(Vec3f{}, Vec3f{0, 0, 1}, Vec3f{0, 1, 0}, 40)
+
+	img, _, err := scene.Render(camera, 9, 9, RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := img.frameBuffer[4*9+4], emission.mul(0.5); !approxVec(got, want, 1e-6) {
+		t.Errorf("center pixel = %v, want the emission %v", got, want)
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+// Glossy is a diffuse material under a reflective coat, like varnished wood or
+// plastic. The reflection is weighted by the Fresnel term of the coat, whose
+// reflectance at normal incidence is f0: it is faint when the surface is seen
+// from the front and grows towards grazing angles, where it hides the base color.
+type Glossy struct {
+	color Vec3f
+	f0    Vec3f
+}
+
+func (g Glossy) render(rdi Vec3f, hit Hit, scene Scene, ctx rayContext) Vec3f {
+	// Couleur de la base diffuse
+	base := Lambert{g.color}.render(rdi, hit, scene, ctx)
+
+	// Part réfléchie par le vernis selon l'angle de vue
+	omega, n := hit.point, hit.normal
+	V := rdi.inverte().normalized()
+	F := fresnelSchlick(max(Dot(n, V), 0), g.f0)
+
+	rd := reflect(rdi, n).normalized()
+	weight, _ := F.maxComponent()
+	reflected := renderPixel(scene, ctx.offset(omega, n), rd, ctx.child().weighted(weight))
+
+	return Add(Mul(Sub(Vec3f{1, 1, 1}, F), base), Mul(F, reflected))
+}
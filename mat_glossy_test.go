@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestGlossyReflectsMoreAtGrazing(t *testing.T) {
+	// Base noire et ciel blanc : seul le reflet du vernis est visible
+	scene := Scene{}
+	scene.setBackground(Vec3f{1, 1, 1})
+	scene.addElement(Plane{point: Vec3f{}, normal: Vec3f{0, 1, 0}, Material: Glossy{Vec3f{}, Vec3f{0.04, 0.04, 0.04}}})
+
+	front := shade(t, scene, Vec3f{0, 1, 0}, Vec3f{0, -1, 0})
+	grazing := shade(t, scene, Vec3f{0, 1, 0}, Vec3f{1, -0.1, 0}.normalized())
+	if !approxVec(front, Vec3f{0.04, 0.04, 0.04}, 1e-3) {
+		t.Errorf("reflection seen from the front = %v, want f0", front)
+	}
+	if grazing.x <= 2*front.x {
+		t.Errorf("reflection at grazing angle = %v, want much more than %v from the front", grazing, front)
+	}
+}
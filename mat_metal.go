@@ -0,0 +1,25 @@
+package main
+
+// Metal is a reflective material tinting what it reflects by albedo. The reflected
+// ray is perturbed by a random vector of length up to fuzz, which blurs the
+// reflection: 0 gives a perfect mirror, 1 a very rough metal.
+type Metal struct {
+	albedo Vec3f
+	fuzz   float32
+}
+
+func (m Metal) render(rdi Vec3f, hit Hit, scene Scene, ctx rayContext) Vec3f {
+	omega, n := hit.point, hit.normal
+	rd := reflect(rdi.normalized(), n)
+	if m.fuzz > 0 {
+		rd = Add(rd, randomUnitVector(ctx.rng).mul(m.fuzz*ctx.rng.Float32()))
+	}
+	// Un rayon perturbé qui repart sous la surface est absorbé
+	if Dot(rd, n) <= 0 {
+		return Vec3f{}
+	}
+
+	weight, _ := m.albedo.maxComponent()
+	reflected := renderPixel(scene, ctx.offset(omega, n), rd.normalized(), ctx.child().weighted(weight))
+	return Mul(m.albedo, reflected)
+}
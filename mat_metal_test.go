@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestMetalFuzz(t *testing.T) {
+	// Ciel en dégradé : la couleur reflétée révèle la direction du rayon réfléchi
+	sky := Scene{}
+	sky.setSkyGradient(Vec3f{0, 0, 0}, Vec3f{1, 1, 1})
+	reflections := func(fuzz float32) map[Vec3f]bool {
+		scene := sky
+		scene.addElement(Plane{point: Vec3f{}, normal: Vec3f{0, 1, 0}, Material: Metal{Vec3f{1, 1, 1}, fuzz}})
+		ro, rd := Vec3f{-1, 1, 0}, Vec3f{1, -1, 0}.normalized()
+		hit, _ := scene.intersect(ro, rd)
+		ctx := testContext()
+		colors := map[Vec3f]bool{}
+		for i := 0; i < 20; i++ {
+			colors[hit.material.render(rd, hit, scene, ctx)] = true
+		}
+		return colors
+	}
+
+	want := sky.backgroundColor(Vec3f{1, 1, 0}.normalized())
+	if mirror := reflections(0); len(mirror) != 1 || !mirror[want] {
+		t.Errorf("fuzz 0 reflects %v, want only the sky in the mirror direction %v", mirror, want)
+	}
+	if rough := reflections(0.5); len(rough) < 10 {
+		t.Errorf("fuzz 0.5 gives %d distinct colors out of 20 rays, want varied directions", len(rough))
+	}
+}
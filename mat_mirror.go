@@ -0,0 +1,22 @@
+package main
+
+// Mirror is a reflective material. The incoming ray is reflected about the surface
+// normal and traced again through the scene; the reflected color is then blended
+// with the diffuse color of the surface according to reflectivity (0: diffuse only,
+// 1: perfect mirror).
+type Mirror struct {
+	kd           Vec3f
+	reflectivity float32
+}
+
+func (m Mirror) render(rdi Vec3f, hit Hit, scene Scene, ctx rayContext) Vec3f {
+	// Couleur propre de la surface
+	surface := Lambert{m.kd}.render(rdi, hit, scene, ctx)
+
+	// Rayon réfléchi, décalé le long de la normale pour ne pas toucher la surface de départ
+	omega, n := hit.point, hit.normal
+	rd := reflect(rdi, n).normalized()
+	reflected := renderPixel(scene, ctx.offset(omega, n), rd, ctx.child().weighted(m.reflectivity))
+
+	return Lerp(surface, reflected, m.reflectivity)
+}
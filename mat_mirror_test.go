@@ -0,0 +1,16 @@
+package main
+
+import "testing"
+
+func TestMirrorReflectsRedSphere(t *testing.T) {
+	scene := Scene{}
+	scene.addElement(Sphere{1, Vec3f{0, 0, 5}, Mirror{Vec3f{}, 1}})
+	// Sphère rouge derrière la caméra, vue seulement dans le miroir
+	scene.addElement(Sphere{1, Vec3f{0, 0, -5}, Lambert{Vec3f{1, 0, 0}}})
+	scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{0, 2, -2}})
+
+	c := renderPixel(scene, Vec3f{}, Vec3f{0, 0, 1}, testContext())
+	if c.x <= 0 || c.y != 0 || c.z != 0 {
+		t.Errorf("reflection = %v, want pure red", c)
+	}
+}
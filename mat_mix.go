@@ -0,0 +1,21 @@
+package main
+
+// Mix blends two materials, such as a diffuse base and a mirror: weight is the part
+// of b in the color, from 0 (only a) to 1 (only b).
+type Mix struct {
+	a, b   Materials
+	weight float32
+}
+
+func (m Mix) render(rdi Vec3f, hit Hit, scene Scene, ctx rayContext) Vec3f {
+	// Un matériau de poids nul n'est pas rendu, ce qui épargne ses rayons secondaires ;
+	// chacun reçoit un contexte pondéré par sa part pour la roulette russe
+	var ca, cb Vec3f
+	if m.weight < 1 {
+		ca = m.a.render(rdi, hit, scene, ctx.weighted(1-m.weight))
+	}
+	if m.weight > 0 {
+		cb = m.b.render(rdi, hit, scene, ctx.weighted(m.weight))
+	}
+	return Lerp(ca, cb, m.weight)
+}
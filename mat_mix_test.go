@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestMixAverages(t *testing.T) {
+	red, blue := Emissive{Vec3f{1, 0, 0}, 1}, Emissive{Vec3f{0, 0, 1}, 1}
+	for _, tt := range []struct {
+		weight float32
+		want   Vec3f
+	}{
+		{0, Vec3f{1, 0, 0}},
+		{0.5, Vec3f{0.5, 0, 0.5}},
+		{1, Vec3f{0, 0, 1}},
+	} {
+		scene := Scene{}
+		scene.addElement(Sphere{1, Vec3f{0, 0, 5}, Mix{red, blue, tt.weight}})
+		if got := shade(t, scene, Vec3f{}, Vec3f{0, 0, 1}); !approxVec(got, tt.want, 1e-6) {
+			t.Errorf("Mix(red, blue, %v) = %v, want %v", tt.weight, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package main
+
+// Noise is a procedural material blending between the colors low and high
+// following a fractal noise over the hit point, frequency setting the size of its
+// features (about 1/frequency). The resulting color is shaded as a Lambertian surface.
+type Noise struct {
+	low, high Vec3f
+	frequency float32
+}
+
+func (no Noise) render(rdi Vec3f, hit Hit, scene Scene, ctx rayContext) Vec3f {
+	f := fractalNoise(hit.point.mul(no.frequency), noiseOctaves)
+	return Lambert{Lerp(no.low, no.high, f)}.render(rdi, hit, scene, ctx)
+}
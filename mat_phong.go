@@ -1,40 +1,56 @@
 package main
 
+// Phong is the classic Phong material: an ambient term ka lit once by the ambient
+// light of the scene, plus for each light a diffuse term kd and a specular term ks
+// of exponent n. The sum is clamped to [0, 1].
 type Phong struct {
 	ka, kd, ks Vec3f
 	n          float32
 }
 
-func (l Phong) render(rio, rdi, n Vec3f, t float32, scene Scene) rgbRepresentation {
-	// --- Etape 1
-	Ia := Mul(l.ka, scene.ambiantLight)
-
-	// --- Etape 2
+func (l Phong) render(rdi Vec3f, hit Hit, scene Scene, ctx rayContext) Vec3f {
 	// Point d'intersection
-	omega := Add(rio, rdi.mul(t))
-	// Vecteur point d'intersection -> lumière
-	vec_intersect_light := Sub(scene.lights[0].position, omega).normalized()
-	L := vec_intersect_light
-
+	omega, n := hit.point, hit.normal
 	n.normalize()
 
-	// Intensité lumineuse
-	I := scene.lights[0].color
-	Id := Mul(l.kd, I.mul(Dot(L, n)))
+	// --- Etape 1 : terme ambiant, ajouté une seule fois pour la scène
+	Ia := Mul(l.ka, scene.ambientAt(n))
 
-	// --- Etape 3
-	R := vec_intersect_light.normalized()
+	// --- Etape 2 : diffuse et spéculaire de chaque lumière
 	V := rdi.inverte().normalized()
-	Is := Mul(l.ks, I).mul(Pow(Dot(R, V), l.n))
-	// fmt.Println("-----")
-	// fmt.Println(R)
-	// fmt.Println(V)
+
+	Id := Vec3f{}
+	Is := Vec3f{}
+	for _, light := range scene.lights {
+		// Vecteur point d'intersection -> lumière et intensité lumineuse (atténuée)
+		L, I, _ := light.illuminate(omega)
+		// Une lumière derrière la surface n'apporte ni diffuse ni spéculaire
+		if Dot(L, n) <= 0 {
+			continue
+		}
+
+		// Si un objet se trouve entre le point et la lumière, elle ne contribue pas (ou en partie)
+		visibility := scene.visibility(light, ctx.offset(omega, n), ctx)
+		if visibility == 0 {
+			continue
+		}
+		diffuse, specular := l.lightContribution(L, I.mul(visibility), n, V)
+		Id = Add(Id, diffuse)
+		Is = Add(Is, specular)
+	}
 
 	// --- Finish
-	res := Add(Add(Ia, Id), Is)
-	// res := Add(Ia, Id)
-	_ = Ia
-	_ = Id
-	_ = Is
-	return rgbRepresentation{uint8(res.x * 255), uint8(res.y * 255), uint8(res.z * 255)}
+	// L'occlusion ambiante atténue les composantes ambiante et diffuse
+	ao := scene.ambientOcclusion(omega, n, ctx)
+	return clamp01(Add(Add(Ia, Id).mul(ao), Is))
+}
+
+// lightContribution returns the diffuse and specular terms of a light of color I
+// coming from the unit direction L, for the unit normal n and view direction V.
+func (l Phong) lightContribution(L, I, n, V Vec3f) (Vec3f, Vec3f) {
+	diffuse := Mul(l.kd, I).mul(max(Dot(L, n), 0))
+	// Réflexion de la direction de la lumière par rapport à la normale
+	R := reflect(L.inverte(), n).normalized()
+	specular := Mul(l.ks, I).mul(Pow(max(Dot(R, V), 0), l.n))
+	return diffuse, specular
 }
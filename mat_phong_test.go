@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPhongAmbientBrightensShadow(t *testing.T) {
+	floor := Phong{Vec3f{0.5, 0.5, 0.5}, Vec3f{0.5, 0.5, 0.5}, Vec3f{0.5, 0.5, 0.5}, 10}
+	scene := Scene{}
+	scene.addElement(Plane{point: Vec3f{}, normal: Vec3f{0, 1, 0}, Material: floor})
+	scene.addElement(Sphere{1, Vec3f{0, 5, 0}, Lambert{Vec3f{1, 1, 1}}})
+	scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{0, 10, 0}})
+	ro, rd := Vec3f{0, 1, 0}, Vec3f{0, -1, 0}
+
+	scene.setAmbient(Vec3f{0.1, 0.1, 0.1})
+	dim := shade(t, scene, ro, rd)
+	scene.setAmbient(Vec3f{0.4, 0.4, 0.4})
+	bright := shade(t, scene, ro, rd)
+	if bright.x <= dim.x || bright.y <= dim.y || bright.z <= dim.z {
+		t.Errorf("shadowed point = %v with more ambient light, want brighter than %v", bright, dim)
+	}
+}
+
+func TestPhongSpecularPeakAlongMirrorDirection(t *testing.T) {
+	m := Phong{Vec3f{}, Vec3f{}, Vec3f{1, 1, 1}, 20}
+	scene := Scene{}
+	scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{-5, 5, 0}})
+	hit := Hit{point: Vec3f{}, normal: Vec3f{0, 1, 0}, material: m}
+
+	// Le spectateur s'éloigne de la direction miroir de la lumière, à 45° de l'autre côté
+	var previous float32
+	for i, offset := range []float64{0, 5, 10, 20, 30} {
+		angle := (45 - offset) * math.Pi / 180
+		viewer := Vec3f{float32(math.Cos(angle)), float32(math.Sin(angle)), 0}
+		c := m.render(viewer.inverte(), hit, scene, testContext())
+		if i == 0 && !approx(c.x, 1, 1e-5) {
+			t.Errorf("specular along the mirror direction = %v, want the full highlight 1", c.x)
+		}
+		if i > 0 && c.x >= previous {
+			t.Errorf("specular %v° off the mirror direction = %v, want less than %v", offset, c.x, previous)
+		}
+		previous = c.x
+	}
+}
+
+func TestPhongTwoLightsTwoHighlights(t *testing.T) {
+	scene := Scene{}
+	scene.addElement(Sphere{1, Vec3f{0, 0, 5}, Phong{Vec3f{}, Vec3f{}, Vec3f{1, 1, 1}, 50}})
+	scene.addLight(Light{color: Vec3f{1, 0, 0}, position: Vec3f{-4, 2, 0}})
+	scene.addLight(Light{color: Vec3f{0, 0, 1}, position: Vec3f{4, 2, 0}})
+	camera, _ := NewCamera(Vec3f{}, Vec3f{0, 0, 1}, Vec3f{0, 1, 0}, 40)
+	const width, height = 41, 41
+	img, _, err := scene.Render(camera, width, height, RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pixel le plus brillant de chaque couleur : chaque lumière y donne son reflet
+	var red, blue int
+	for i, c := range img.frameBuffer {
+		if c.x > img.frameBuffer[red].x {
+			red = i
+		}
+		if c.z > img.frameBuffer[blue].z {
+			blue = i
+		}
+	}
+	redHighlight, blueHighlight := img.frameBuffer[red], img.frameBuffer[blue]
+	if redHighlight.x <= redHighlight.z || blueHighlight.z <= blueHighlight.x {
+		t.Errorf("highlights = %v and %v, want a red one and a blue one", redHighlight, blueHighlight)
+	}
+	// Les lumières de part et d'autre de la sphère donnent deux reflets bien séparés
+	if gap := red%width - blue%width; gap > -4 && gap < 4 {
+		t.Errorf("highlights at columns %d and %d, want at least 4 pixels apart", red%width, blue%width)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+)
+
+// Texture is a material wrapping an image around spheres: the surface normal is
+// converted to spherical UV coordinates used to look up the texel, which is then
+// shaded as a Lambertian surface. Texels are looked up with the nearest-neighbor
+// filter, or interpolated when bilinear is set.
+type Texture struct {
+	texels        []Vec3f
+	width, height int
+	bilinear      bool
+}
+
+// LoadTexture decodes the PNG or JPEG image at path into a Texture.
+func LoadTexture(path string) (Texture, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Texture{}, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return Texture{}, err
+	}
+
+	bounds := img.Bounds()
+	tex := Texture{
+		texels: make([]Vec3f, bounds.Dx()*bounds.Dy()),
+		width:  bounds.Dx(),
+		height: bounds.Dy(),
+	}
+	for y := 0; y < tex.height; y++ {
+		for x := 0; x < tex.width; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			tex.texels[y*tex.width+x] = Vec3f{float32(r) / 0xffff, float32(g) / 0xffff, float32(b) / 0xffff}
+		}
+	}
+	return tex, nil
+}
+
+// sphericalUV converts a unit normal to spherical texture coordinates in [0, 1].
+func sphericalUV(n Vec3f) (float32, float32) {
+	u := 0.5 + float32(math.Atan2(float64(n.z), float64(n.x)))/(2*math.Pi)
+	v := 0.5 - float32(math.Asin(float64(min(max(n.y, -1), 1))))/math.Pi
+	return u, v
+}
+
+// sample returns the texel at the coordinates (u, v), u wrapping around
+// horizontally and v being clamped vertically.
+func (tex Texture) sample(u, v float32) Vec3f {
+	if len(tex.texels) == 0 {
+		return Vec3f{}
+	}
+	u -= float32(math.Floor(float64(u)))
+	v = min(max(v, 0), 1)
+
+	x := min(int(u*float32(tex.width)), tex.width-1)
+	y := min(int(v*float32(tex.height)), tex.height-1)
+	return tex.texels[y*tex.width+x]
+}
+
+// texel returns the texel of the column x and the row y, x wrapping around
+// horizontally and y being clamped vertically as in sample.
+func (tex Texture) texel(x, y int) Vec3f {
+	x %= tex.width
+	if x < 0 {
+		x += tex.width
+	}
+	y = min(max(y, 0), tex.height-1)
+	return tex.texels[y*tex.width+x]
+}
+
+// sampleBilinear returns the color at the coordinates (u, v) interpolated between
+// the four texels whose centers surround it, with the same borders as sample.
+func (tex Texture) sampleBilinear(u, v float32) Vec3f {
+	if len(tex.texels) == 0 {
+		return Vec3f{}
+	}
+	// Position relative aux centres des texels
+	fx := u*float32(tex.width) - 0.5
+	fy := v*float32(tex.height) - 0.5
+	x0 := int(math.Floor(float64(fx)))
+	y0 := int(math.Floor(float64(fy)))
+	tx := fx - float32(x0)
+	ty := fy - float32(y0)
+
+	top := Lerp(tex.texel(x0, y0), tex.texel(x0+1, y0), tx)
+	bottom := Lerp(tex.texel(x0, y0+1), tex.texel(x0+1, y0+1), tx)
+	return Lerp(top, bottom, ty)
+}
+
+// render maps the texture with the coordinates of the hit, or spherically around
+// the normal when the object defines none.
+func (tex Texture) render(rdi Vec3f, hit Hit, scene Scene, ctx rayContext) Vec3f {
+	u, v := hit.uv.x, hit.uv.y
+	if !hit.hasUV {
+		u, v = sphericalUV(hit.normal.normalized())
+	}
+	var color Vec3f
+	if tex.bilinear {
+		color = tex.sampleBilinear(u, v)
+	} else {
+		color = tex.sample(u, v)
+	}
+	return Lambert{color}.render(rdi, hit, scene, ctx)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTextureTexelOnSphere(t *testing.T) {
+	// Texture noire de 4 × 2 texels dont seul le texel (2, 0) est rouge : il couvre
+	// u dans [0.5, 0.75) et v dans [0, 0.5), soit l'hémisphère nord entre +x et +z
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			img.Set(x, y, color.RGBA{0, 0, 0, 255})
+		}
+	}
+	img.Set(2, 0, color.RGBA{255, 0, 0, 255})
+	path := filepath.Join(t.TempDir(), "texel.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	tex, err := LoadTexture(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Point (u, v) = (0.625, 0.25) de la sphère : 45° de longitude et de latitude
+	inside := Vec3f{0.5, 0.70710677, 0.5}
+	outside := Vec3f{-0.5, -0.70710677, -0.5}
+	for _, tt := range []struct {
+		n    Vec3f
+		want Vec3f
+	}{
+		{inside, Vec3f{1, 0, 0}},
+		{outside, Vec3f{0, 0, 0}},
+	} {
+		scene := Scene{}
+		scene.addElement(Sphere{1, Vec3f{}, tex})
+		scene.addLight(Light{color: Vec3f{1, 1, 1}, position: tt.n.mul(10)})
+		c := shade(t, scene, tt.n.mul(5), tt.n.inverte())
+		lit := c.x > 0
+		if lit != (tt.want.x > 0) || c.y != 0 || c.z != 0 {
+			t.Errorf("point %v = %v, want the color of texel %v", tt.n, c, tt.want)
+		}
+	}
+}
+
+func TestTextureBilinearCenter(t *testing.T) {
+	tex := Texture{
+		texels:   []Vec3f{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}, {1, 1, 1}},
+		width:    2,
+		height:   2,
+		bilinear: true,
+	}
+	// Le centre est à égale distance des centres des quatre texels
+	want := Vec3f{0.5, 0.5, 0.5}
+	if got := tex.sampleBilinear(0.5, 0.5); !approxVec(got, want, 1e-6) {
+		t.Errorf("sampleBilinear(0.5, 0.5) = %v, want the average %v", got, want)
+	}
+	// Au centre d'un texel, seul ce texel compte
+	if got := tex.sampleBilinear(0.25, 0.75); !approxVec(got, tex.texels[2], 1e-6) {
+		t.Errorf("sampleBilinear(0.25, 0.75) = %v, want the texel %v", got, tex.texels[2])
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import "math"
+
+// noiseOctaves is the number of layers of value noise summed by fractalNoise.
+const noiseOctaves = 4
+
+// latticeValue returns a pseudo-random value in [0, 1] attached to the point of
+// integer coordinates (x, y, z). It only depends on the coordinates.
+func latticeValue(x, y, z int32) float32 {
+	h := uint32(x)*73856093 ^ uint32(y)*19349663 ^ uint32(z)*83492791
+	h = (h ^ (h >> 13)) * 1274126177
+	h ^= h >> 16
+	return float32(h&0xffffff) / 0xffffff
+}
+
+// valueNoise returns the value noise at p, in [0, 1]: the values of the eight
+// corners of the unit lattice cell containing p are interpolated with a smooth fade,
+// so that the noise is continuous and has no visible grid.
+func valueNoise(p Vec3f) float32 {
+	fx, fy, fz := math.Floor(float64(p.x)), math.Floor(float64(p.y)), math.Floor(float64(p.z))
+	x, y, z := int32(fx), int32(fy), int32(fz)
+	tx := smoothstep(0, 1, p.x-float32(fx))
+	ty := smoothstep(0, 1, p.y-float32(fy))
+	tz := smoothstep(0, 1, p.z-float32(fz))
+
+	lerp := func(a, b, t float32) float32 { return a + (b-a)*t }
+	corner := func(dx, dy, dz int32) float32 { return latticeValue(x+dx, y+dy, z+dz) }
+
+	bottom := lerp(lerp(corner(0, 0, 0), corner(1, 0, 0), tx), lerp(corner(0, 1, 0), corner(1, 1, 0), tx), ty)
+	top := lerp(lerp(corner(0, 0, 1), corner(1, 0, 1), tx), lerp(corner(0, 1, 1), corner(1, 1, 1), tx), ty)
+	return lerp(bottom, top, tz)
+}
+
+// fractalNoise sums octaves layers of value noise, each one twice as fine and half
+// as strong as the previous one, and returns the result in [0, 1].
+func fractalNoise(p Vec3f, octaves int) float32 {
+	sum, amplitude, total := float32(0), float32(1), float32(0)
+	for i := 0; i < octaves; i++ {
+		sum += valueNoise(p) * amplitude
+		total += amplitude
+		p = p.mul(2)
+		amplitude /= 2
+	}
+	if total == 0 {
+		return 0
+	}
+	return sum / total
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestNoiseDeterministicInRange(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		p := Vec3f{rng.Float32()*200 - 100, rng.Float32()*200 - 100, rng.Float32()*200 - 100}
+		v := fractalNoise(p, noiseOctaves)
+		if v < 0 || v > 1 {
+			t.Fatalf("fractalNoise(%v) = %v, want in [0, 1]", p, v)
+		}
+		if again := fractalNoise(p, noiseOctaves); again != v {
+			t.Fatalf("fractalNoise(%v) = %v then %v, want the same value", p, v, again)
+		}
+		if n := valueNoise(p); n < 0 || n > 1 {
+			t.Fatalf("valueNoise(%v) = %v, want in [0, 1]", p, n)
+		}
+	}
+}
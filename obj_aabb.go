@@ -0,0 +1,127 @@
+package main
+
+import "math"
+
+// AABB is an axis-aligned bounding box going from the corner min to the corner max.
+// It is used both as a renderable box, in which case Material must be set, and as
+// bounding volume for other primitives.
+type AABB struct {
+	min, max Vec3f
+	Material Materials
+}
+
+// infiniteAABB is the bounding box of unbounded objects such as planes.
+var infiniteAABB = AABB{
+	min: Vec3f{float32(math.Inf(-1)), float32(math.Inf(-1)), float32(math.Inf(-1))},
+	max: Vec3f{float32(math.Inf(1)), float32(math.Inf(1)), float32(math.Inf(1))},
+}
+
+// isFinite reports whether the box has finite bounds.
+func (b AABB) isFinite() bool {
+	for _, c := range [6]float32{b.min.x, b.min.y, b.min.z, b.max.x, b.max.y, b.max.z} {
+		if math.IsInf(float64(c), 0) || math.IsNaN(float64(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// union returns the smallest box containing both boxes.
+func union(a, b AABB) AABB {
+	return AABB{
+		min: Vec3f{min(a.min.x, b.min.x), min(a.min.y, b.min.y), min(a.min.z, b.min.z)},
+		max: Vec3f{max(a.max.x, b.max.x), max(a.max.y, b.max.y), max(a.max.z, b.max.z)},
+	}
+}
+
+// centroid returns the center of the box.
+func (b AABB) centroid() Vec3f {
+	return Add(b.min, b.max).mul(0.5)
+}
+
+// slabs intersects the ray with the three pairs of planes bounding the box (slab method).
+// It returns the distances at which the ray enters and leaves the box, the entry
+// being negative when the origin is inside, and false if the line misses the box.
+func (b AABB) slabs(ro, rd Vec3f) (bool, float32, float32) {
+	tmin := float32(math.Inf(-1))
+	tmax := float32(math.Inf(1))
+
+	for axis := 0; axis < 3; axis++ {
+		o, d := ro.At(axis), rd.At(axis)
+		lo, hi := b.min.At(axis), b.max.At(axis)
+
+		// Rayon parallèle aux plans : il doit déjà être entre les deux
+		if d == 0 {
+			if o < lo || o > hi {
+				return false, 0.0, 0.0
+			}
+			continue
+		}
+
+		inv := 1 / d
+		t0 := (lo - o) * inv
+		t1 := (hi - o) * inv
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		tmin = max(tmin, t0)
+		tmax = min(tmax, t1)
+		if tmin > tmax {
+			return false, 0.0, 0.0
+		}
+	}
+	return true, tmin, tmax
+}
+
+// hit determines if a ray intersects with the box using the slab method.
+// It returns the distance to the entry point, or to the exit point if the
+// ray origin is inside the box.
+func (b AABB) hit(ro, rd Vec3f) (bool, float32) {
+	ok, tmin, tmax := b.slabs(ro, rd)
+	if !ok || tmax < 0 {
+		return false, 0.0
+	}
+	if tmin > 0 {
+		return true, tmin
+	}
+	return true, tmax
+}
+
+// normalAt returns the normal of the face of the box the point p lies on.
+func (b AABB) normalAt(p Vec3f) Vec3f {
+	center := b.centroid()
+	half := Sub(b.max, b.min).mul(0.5)
+	d := Sub(p, center)
+	// Position relative de p sur chaque axe : la face touchée est celle où elle vaut ±1
+	rx, ry, rz := abs32(d.x/half.x), abs32(d.y/half.y), abs32(d.z/half.z)
+	switch {
+	case rx >= ry && rx >= rz:
+		return Vec3f{sign32(d.x), 0, 0}
+	case ry >= rz:
+		return Vec3f{0, sign32(d.y), 0}
+	default:
+		return Vec3f{0, 0, sign32(d.z)}
+	}
+}
+
+func (b AABB) bounds() AABB {
+	return AABB{min: b.min, max: b.max}
+}
+
+// boundingSphere returns the sphere circumscribed to the box, of infinite radius if
+// the box is not finite. Objects with no tighter sphere use the one of their bounds.
+func (b AABB) boundingSphere() (Vec3f, float32) {
+	if !b.isFinite() {
+		return Vec3f{}, float32(math.Inf(1))
+	}
+	return b.centroid(), Sub(b.max, b.min).norme() / 2
+}
+
+func (b AABB) isIntersectedByRay(ro, rd Vec3f) (bool, float32) {
+	return b.hit(ro, rd)
+}
+
+// surface returns the normal of the face of the box the point p lies on.
+func (b AABB) surface(p, rd Vec3f) (Vec3f, Materials) {
+	return b.normalAt(p), b.Material
+}
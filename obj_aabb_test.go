@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestAABBHit(t *testing.T) {
+	box := AABB{min: Vec3f{-1, -1, -1}, max: Vec3f{1, 1, 1}}
+	tests := []struct {
+		name   string
+		ro, rd Vec3f
+		hit    bool
+		t      float32
+	}{
+		{"entering", Vec3f{0, 0, -5}, Vec3f{0, 0, 1}, true, 4},
+		{"entering obliquely", Vec3f{-3, 0, -3}, Vec3f{1, 0, 1}, true, 2},
+		{"missing", Vec3f{0, 3, -5}, Vec3f{0, 0, 1}, false, 0},
+		{"parallel outside a slab", Vec3f{2, 0, -5}, Vec3f{0, 0, 1}, false, 0},
+		{"pointing away", Vec3f{0, 0, -5}, Vec3f{0, 0, -1}, false, 0},
+		{"origin inside", Vec3f{0, 0.5, 0}, Vec3f{0, 1, 0}, true, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit, dist := box.hit(tt.ro, tt.rd)
+			if hit != tt.hit {
+				t.Fatalf("hit = %v, want %v", hit, tt.hit)
+			}
+			if hit && !approx(dist, tt.t, 1e-6) {
+				t.Errorf("t = %v, want %v", dist, tt.t)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+// Box is a renderable rectangular box. It is stored as an AABB centered on the
+// origin, placed in the scene by transform, so that it can be rotated.
+type Box struct {
+	extent    AABB
+	transform Transform
+}
+
+// NewBox returns an axis-aligned box of the given size centered on center.
+func NewBox(center, size Vec3f, m Materials) Box {
+	half := size.mul(0.5)
+	return Box{AABB{half.inverte(), half, m}, translation(center)}
+}
+
+// rotated returns the box rotated by angle degrees around axis going through its center.
+func (b Box) rotated(axis Vec3f, angle float32) Box {
+	b.transform = rotation(axis, angle).then(b.transform)
+	return b
+}
+
+// object returns the box as the transformed AABB doing the actual work.
+func (b Box) object() Transformed {
+	return Transformed{b.extent, b.transform}
+}
+
+// isIntersectedByRay determines if a ray intersects with the box using the slab
+// method of AABB in the frame of the box.
+func (b Box) isIntersectedByRay(ro, rd Vec3f) (bool, float32) {
+	return b.object().isIntersectedByRay(ro, rd)
+}
+
+// surface returns the normal of the face of the box the point p lies on.
+func (b Box) surface(p, rd Vec3f) (Vec3f, Materials) {
+	return b.object().surface(p, rd)
+}
+
+func (b Box) bounds() AABB {
+	return b.object().bounds()
+}
+
+func (b Box) boundingSphere() (Vec3f, float32) {
+	return b.bounds().boundingSphere()
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestBoxFaceNormals(t *testing.T) {
+	box := NewBox(Vec3f{1, 2, 3}, Vec3f{2, 4, 6}, nil)
+	for _, tt := range []struct {
+		name       string
+		box        Box
+		ro, rd     Vec3f
+		wantT      float32
+		wantNormal Vec3f
+	}{
+		{"+x face", box, Vec3f{10, 2, 3}, Vec3f{-1, 0, 0}, 8, Vec3f{1, 0, 0}},
+		{"top face", box, Vec3f{1, 10, 3}, Vec3f{0, -1, 0}, 6, Vec3f{0, 1, 0}},
+		// Tournée d'un quart de tour autour de y, la boîte fait 6 de large selon x
+		{"+x face of the rotated box", box.rotated(Vec3f{0, 1, 0}, 90), Vec3f{10, 2, 3}, Vec3f{-1, 0, 0}, 6, Vec3f{1, 0, 0}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, dist := tt.box.isIntersectedByRay(tt.ro, tt.rd)
+			if !ok || !approx(dist, tt.wantT, 1e-4) {
+				t.Fatalf("isIntersectedByRay = %v, %v, want true, %v", ok, dist, tt.wantT)
+			}
+			n, _ := tt.box.surface(Add(tt.ro, tt.rd.mul(dist)), tt.rd)
+			if !approxVec(n, tt.wantNormal, 1e-4) {
+				t.Errorf("normal = %v, want %v", n, tt.wantNormal)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import "math"
+
+// Cylinder represents a finite closed cylinder going from base along the unit
+// vector axis over height, with end caps.
+type Cylinder struct {
+	base     Vec3f
+	axis     Vec3f
+	radius   float32
+	height   float32
+	Material Materials
+}
+
+// normalAt returns the normal of the cylinder at the point p of its surface:
+// the axis on the caps, and the direction from the closest point of the axis
+// on the side.
+func (c Cylinder) normalAt(p Vec3f) Vec3f {
+	h := Dot(Sub(p, c.base), c.axis)
+	switch {
+	case h <= 1e-4:
+		return c.axis.inverte()
+	case h >= c.height-1e-4:
+		return c.axis
+	}
+	return Sub(p, Add(c.base, c.axis.mul(h))).normalized()
+}
+
+func (c Cylinder) surface(p, rd Vec3f) (Vec3f, Materials) {
+	return c.normalAt(p), c.Material
+}
+
+func (c Cylinder) bounds() AABB {
+	top := Add(c.base, c.axis.mul(c.height))
+	// Demi-étendue des disques des extrémités sur chaque axe
+	extent := Vec3f{
+		c.radius * float32(math.Sqrt(float64(max(1-c.axis.x*c.axis.x, 0)))),
+		c.radius * float32(math.Sqrt(float64(max(1-c.axis.y*c.axis.y, 0)))),
+		c.radius * float32(math.Sqrt(float64(max(1-c.axis.z*c.axis.z, 0)))),
+	}
+	return union(
+		AABB{min: Sub(c.base, extent), max: Add(c.base, extent)},
+		AABB{min: Sub(top, extent), max: Add(top, extent)},
+	)
+}
+
+func (c Cylinder) boundingSphere() (Vec3f, float32) {
+	return c.bounds().boundingSphere()
+}
+
+// isIntersectedByRay determines if a ray intersects with the cylinder.
+// The side is intersected by solving the quadratic of the infinite cylinder and
+// keeping the roots within the height range; the caps are intersected as disks.
+func (c Cylinder) isIntersectedByRay(ro, rd Vec3f) (bool, float32) {
+	found := false
+	tmin := float32(math.Inf(1))
+	keep := func(t float32) {
+		if t > 1e-4 && t < tmin {
+			tmin = t
+			found = true
+		}
+	}
+
+	// Composantes du rayon orthogonales à l'axe
+	oc := Sub(ro, c.base)
+	d := Sub(rd, c.axis.mul(Dot(rd, c.axis)))
+	o := Sub(oc, c.axis.mul(Dot(oc, c.axis)))
+
+	a := Dot(d, d)
+	b := 2 * Dot(d, o)
+	cc := Dot(o, o) - c.radius*c.radius
+	delta := b*b - 4*a*cc
+	if a > 1e-8 && delta >= 0 {
+		sqrtDelta := float32(math.Sqrt(float64(delta)))
+		for _, t := range [2]float32{(-b - sqrtDelta) / (2 * a), (-b + sqrtDelta) / (2 * a)} {
+			h := Dot(Add(oc, rd.mul(t)), c.axis)
+			if h >= 0 && h <= c.height {
+				keep(t)
+			}
+		}
+	}
+
+	// Disques des extrémités
+	denom := Dot(rd, c.axis)
+	if denom < -1e-6 || denom > 1e-6 {
+		for _, center := range [2]Vec3f{c.base, Add(c.base, c.axis.mul(c.height))} {
+			t := Dot(Sub(center, ro), c.axis) / denom
+			if Sub(Add(ro, rd.mul(t)), center).lengthSquared() <= c.radius*c.radius {
+				keep(t)
+			}
+		}
+	}
+
+	if !found {
+		return false, 0.0
+	}
+	return true, tmin
+}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestCylinderIntersection(t *testing.T) {
+	c := Cylinder{base: Vec3f{}, axis: Vec3f{0, 1, 0}, radius: 1, height: 2}
+	tests := []struct {
+		name   string
+		ro, rd Vec3f
+		hit    bool
+		t      float32
+		normal Vec3f
+	}{
+		{"side", Vec3f{-5, 1, 0}, Vec3f{1, 0, 0}, true, 4, Vec3f{-1, 0, 0}},
+		{"top cap", Vec3f{0.5, 5, 0}, Vec3f{0, -1, 0}, true, 3, Vec3f{0, 1, 0}},
+		{"bottom cap", Vec3f{0, -3, 0.5}, Vec3f{0, 1, 0}, true, 3, Vec3f{0, -1, 0}},
+		{"above", Vec3f{-5, 2.5, 0}, Vec3f{1, 0, 0}, false, 0, Vec3f{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit, dist := c.isIntersectedByRay(tt.ro, tt.rd)
+			if hit != tt.hit {
+				t.Fatalf("hit = %v, want %v", hit, tt.hit)
+			}
+			if !hit {
+				return
+			}
+			if !approx(dist, tt.t, 1e-5) {
+				t.Errorf("t = %v, want %v", dist, tt.t)
+			}
+			if n, _ := c.surface(Add(tt.ro, tt.rd.mul(dist)), tt.rd); !approxVec(n, tt.normal, 1e-5) {
+				t.Errorf("normal = %v, want %v", n, tt.normal)
+			}
+		})
+	}
+}
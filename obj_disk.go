@@ -0,0 +1,49 @@
+package main
+
+import "math"
+
+// Disk represents a flat circle of the given radius, centered on center and
+// oriented by normal. Like a Plane, it is lit on both sides unless singleSided is set.
+type Disk struct {
+	center      Vec3f
+	normal      Vec3f
+	radius      float32
+	Material    Materials
+	singleSided bool
+}
+
+// plane returns the supporting plane of the disk.
+func (d Disk) plane() Plane {
+	return Plane{point: d.center, normal: d.normal, Material: d.Material, singleSided: d.singleSided}
+}
+
+func (d Disk) surface(p, rd Vec3f) (Vec3f, Materials) {
+	return d.plane().surface(p, rd)
+}
+
+func (d Disk) bounds() AABB {
+	n := d.normal.normalized()
+	extent := Vec3f{
+		d.radius * float32(math.Sqrt(float64(max(1-n.x*n.x, 0)))),
+		d.radius * float32(math.Sqrt(float64(max(1-n.y*n.y, 0)))),
+		d.radius * float32(math.Sqrt(float64(max(1-n.z*n.z, 0)))),
+	}
+	return AABB{min: Sub(d.center, extent), max: Add(d.center, extent)}
+}
+
+func (d Disk) boundingSphere() (Vec3f, float32) {
+	return d.bounds().boundingSphere()
+}
+
+// isIntersectedByRay intersects the ray with the supporting plane of the disk,
+// then rejects the hits farther than radius from its center.
+func (d Disk) isIntersectedByRay(ro, rd Vec3f) (bool, float32) {
+	isIntersected, t := d.plane().isIntersectedByRay(ro, rd)
+	if !isIntersected {
+		return false, 0.0
+	}
+	if Sub(Add(ro, rd.mul(t)), d.center).lengthSquared() > d.radius*d.radius {
+		return false, 0.0
+	}
+	return true, t
+}
@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestDiskIntersection(t *testing.T) {
+	d := Disk{center: Vec3f{0, 0, 5}, normal: Vec3f{0, 0, -1}, radius: 1}
+	tests := []struct {
+		name   string
+		ro, rd Vec3f
+		hit    bool
+		t      float32
+	}{
+		{"inside the radius", Vec3f{0.5, 0.5, 0}, Vec3f{0, 0, 1}, true, 5},
+		{"just outside the radius", Vec3f{1.01, 0, 0}, Vec3f{0, 0, 1}, false, 0},
+		{"parallel", Vec3f{0, 0, 5}, Vec3f{1, 0, 0}, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit, dist := d.isIntersectedByRay(tt.ro, tt.rd)
+			if hit != tt.hit {
+				t.Fatalf("hit = %v, want %v", hit, tt.hit)
+			}
+			if hit && !approx(dist, tt.t, 1e-5) {
+				t.Errorf("t = %v, want %v", dist, tt.t)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package main
+
+// NewEllipsoid returns an ellipsoid centered on center, of semi-axes radii along x,
+// y and z: a unit sphere scaled non-uniformly then translated. Transformed brings the
+// rays into the space of the sphere and its normals back with the inverse transpose
+// of the scaling, which keeps them orthogonal to the stretched surface.
+func NewEllipsoid(center, radii Vec3f, m Materials) Transformed {
+	return Transformed{Sphere{1, Vec3f{}, m}, scaling(radii).then(translation(center))}
+}
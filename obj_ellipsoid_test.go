@@ -0,0 +1,43 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEllipsoidSilhouette(t *testing.T) {
+	ellipsoid := NewEllipsoid(Vec3f{}, Vec3f{2, 1, 1}, Lambert{Vec3f{1, 1, 1}})
+	for _, tt := range []struct {
+		x, y float32
+		hit  bool
+	}{
+		{1.9, 0, true},
+		{2.1, 0, false},
+		{0, 0.9, true},
+		{0, 1.1, false},
+	} {
+		if ok, _ := ellipsoid.isIntersectedByRay(Vec3f{tt.x, tt.y, -5}, Vec3f{0, 0, 1}); ok != tt.hit {
+			t.Errorf("ray at (%v, %v) hits = %v, want %v", tt.x, tt.y, ok, tt.hit)
+		}
+	}
+}
+
+func TestEllipsoidNormal(t *testing.T) {
+	ellipsoid := NewEllipsoid(Vec3f{}, Vec3f{2, 1, 1}, Lambert{Vec3f{1, 1, 1}})
+	// Point (√2, 1/√2, 0) de la surface x²/4 + y² = 1, touché par un rayon vertical
+	x := float32(math.Sqrt2)
+	ro, rd := Vec3f{x, 5, 0}, Vec3f{0, -1, 0}
+	ok, dist := ellipsoid.isIntersectedByRay(ro, rd)
+	if !ok {
+		t.Fatal("ray misses the ellipsoid")
+	}
+	p := Add(ro, rd.mul(dist))
+	if !approx(p.y, 1/x, 1e-5) {
+		t.Fatalf("hit at %v, want y = %v", p, 1/x)
+	}
+	// La normale suit le gradient (x/a², y/b², z/c²), et non la normale de la sphère étirée
+	n, _ := ellipsoid.surface(p, rd)
+	if want := (Vec3f{p.x / 4, p.y, 0}).normalized(); !approxVec(n, want, 1e-5) {
+		t.Errorf("normal = %v, want %v", n, want)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import "math"
+
+// Mesh groups objects, typically the triangles returned by LoadOBJ, into a single
+// object of the scene. Its bounding sphere contains all of its parts, so that a ray
+// missing it skips the exact test of every part at once instead of culling them one
+// by one; the rays reaching the mesh then cull each part by its own bounding sphere.
+type Mesh struct {
+	parts []GeometricObject
+	box   AABB
+}
+
+// NewMesh returns the mesh made of parts, computing once the box containing them.
+func NewMesh(parts []GeometricObject) Mesh {
+	mesh := Mesh{parts: parts}
+	for i, part := range parts {
+		if i == 0 {
+			mesh.box = part.bounds()
+		} else {
+			mesh.box = union(mesh.box, part.bounds())
+		}
+	}
+	return mesh
+}
+
+// nearestPart returns the part hit first by the ray and the distance to it, or nil
+// if no part is hit. The hit recorded by the scene keeps this part, whose surface is
+// the one of the mesh.
+func (m Mesh) nearestPart(ro, rd Vec3f) (GeometricObject, float32) {
+	var nearest GeometricObject
+	nearestT := float32(math.Inf(1))
+	for _, part := range m.parts {
+		if missesBoundingSphere(part, ro, rd) {
+			continue
+		}
+		// Une partie elle-même composée donne la partie qu'elle a touchée
+		if ok, t, inner := intersectPart(part, ro, rd); ok && t < nearestT {
+			nearest, nearestT = part, t
+			if inner != nil {
+				nearest = inner
+			}
+		}
+	}
+	return nearest, nearestT
+}
+
+func (m Mesh) isIntersectedByRay(ro, rd Vec3f) (bool, float32) {
+	nearest, t := m.nearestPart(ro, rd)
+	if nearest == nil {
+		return false, 0.0
+	}
+	return true, t
+}
+
+// meshRecastDistance is the distance before p from which surface casts the ray again
+// when the part hit is not known.
+const meshRecastDistance = 1e-2
+
+// surface returns the normal and the material of the part of the mesh lying at p. The
+// scene reads them from the part recorded with the hit (see nearestPart); surface is
+// only reached for a mesh nested in another object, such as Transformed, and then
+// casts the ray again from just before p to find the part.
+func (m Mesh) surface(p, rd Vec3f) (Vec3f, Materials) {
+	dir := rd.normalized()
+	part, _ := m.nearestPart(Sub(p, dir.mul(meshRecastDistance)), dir)
+	if part == nil {
+		// Point hors du maillage : la première partie fait office de surface
+		if len(m.parts) == 0 {
+			return dir.inverte(), nil
+		}
+		part = m.parts[0]
+	}
+	return part.surface(p, rd)
+}
+
+func (m Mesh) bounds() AABB {
+	return m.box
+}
+
+func (m Mesh) boundingSphere() (Vec3f, float32) {
+	return m.box.boundingSphere()
+}
@@ -0,0 +1,94 @@
+package main
+
+import "testing"
+
+// countingObject counts the exact intersection tests made on the object it wraps.
+type countingObject struct {
+	GeometricObject
+	calls *int
+}
+
+func (c countingObject) isIntersectedByRay(ro, rd Vec3f) (bool, float32) {
+	*c.calls++
+	return c.GeometricObject.isIntersectedByRay(ro, rd)
+}
+
+// gridMesh returns a mesh of 2 × n × n triangles tiling the square [0, n]² of the
+// plane z = 5, facing -z, each of them counting its tests in calls.
+func gridMesh(n int, calls *int) Mesh {
+	m := Lambert{Vec3f{1, 1, 1}}
+	var parts []GeometricObject
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			a := Vec3f{float32(x), float32(y), 5}
+			b, c, d := Add(a, Vec3f{1, 0, 0}), Add(a, Vec3f{1, 1, 0}), Add(a, Vec3f{0, 1, 0})
+			parts = append(parts,
+				countingObject{Triangle{v0: a, v1: c, v2: b, Material: m}, calls},
+				countingObject{Triangle{v0: a, v1: d, v2: c, Material: m}, calls})
+		}
+	}
+	return NewMesh(parts)
+}
+
+func TestMeshCullsMissedRays(t *testing.T) {
+	calls := 0
+	scene := Scene{}
+	scene.addElement(gridMesh(10, &calls))
+
+	// Rayon passant loin du maillage : aucun triangle n'est testé
+	if object, _ := scene.nearest(Vec3f{50, 50, 0}, Vec3f{0, 0, 1}); object != nil {
+		t.Errorf("ray beside the mesh hit %v", object)
+	}
+	if calls != 0 {
+		t.Errorf("ray missing the mesh made %d triangle tests, want 0", calls)
+	}
+
+	// Rayon touchant le maillage : seuls les triangles proches sont testés
+	object, dist := scene.nearest(Vec3f{2.5, 3.25, 0}, Vec3f{0, 0, 1})
+	if _, ok := object.(Mesh); !ok || !approx(dist, 5, 1e-5) {
+		t.Fatalf("ray towards the mesh = %v at t = %v, want the mesh at t = 5", object, dist)
+	}
+	if calls == 0 || calls >= 200 {
+		t.Errorf("ray hitting the mesh made %d triangle tests, want a few of the 200", calls)
+	}
+}
+
+func TestMeshSurface(t *testing.T) {
+	calls := 0
+	scene := Scene{}
+	scene.addElement(gridMesh(4, &calls))
+	scene.nearest(Vec3f{1.5, 2.5, 0}, Vec3f{0, 0, 1})
+	searchCalls := calls
+	calls = 0
+	hit, ok := scene.intersect(Vec3f{1.5, 2.5, 0}, Vec3f{0, 0, 1})
+	if !ok {
+		t.Fatal("ray misses the mesh")
+	}
+	// La partie touchée est gardée avec le résultat : aucun test de plus pour la surface
+	if calls != searchCalls {
+		t.Errorf("intersect made %d triangle tests, want the %d of the search alone", calls, searchCalls)
+	}
+	if !approxVec(hit.point, Vec3f{1.5, 2.5, 5}, 1e-5) || !approxVec(hit.normal, Vec3f{0, 0, -1}, 1e-6) {
+		t.Errorf("hit at %v with normal %v, want (1.5, 2.5, 5) facing -z", hit.point, hit.normal)
+	}
+	if hit.material == nil {
+		t.Error("hit has no material")
+	}
+}
+
+func TestMeshThinParts(t *testing.T) {
+	// Deux petits triangles parallèles, bien plus proches que leur taille
+	front, back := Lambert{Vec3f{1, 0, 0}}, Lambert{Vec3f{0, 0, 1}}
+	triangle := func(z float32, m Materials) Triangle {
+		return Triangle{v0: Vec3f{0, 0, z}, v1: Vec3f{0, 1e-3, z}, v2: Vec3f{1e-3, 0, z}, Material: m}
+	}
+	scene := Scene{}
+	scene.addElement(NewMesh([]GeometricObject{triangle(5+1e-4, back), triangle(5, front)}))
+	hit, ok := scene.intersect(Vec3f{2e-4, 2e-4, 0}, Vec3f{0, 0, 1})
+	if !ok {
+		t.Fatal("ray misses the mesh")
+	}
+	if hit.material != Materials(front) {
+		t.Errorf("material = %v, want the one of the front triangle %v", hit.material, front)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+// Plane represents an infinite plane going through point and oriented by normal.
+// Both of its sides are lit, unless singleSided is set.
+type Plane struct {
+	point    Vec3f
+	normal   Vec3f
+	Material Materials
+	// Seule la face vers laquelle pointe la normale est éclairée ; sinon la normale
+	// est retournée vers le rayon incident
+	singleSided bool
+}
+
+// surface returns the normal of the plane and its material. The normal is flipped so
+// that it always faces the incident ray, unless the plane is single-sided: seen from
+// behind, it then keeps its normal and is not lit.
+func (p Plane) surface(point, rd Vec3f) (Vec3f, Materials) {
+	n := p.normal.normalized()
+	if !p.singleSided && Dot(n, rd) > 0 {
+		n = n.inverte()
+	}
+	return n, p.Material
+}
+
+// bounds returns infiniteAABB since a plane is unbounded.
+func (p Plane) bounds() AABB {
+	return infiniteAABB
+}
+
+func (p Plane) boundingSphere() (Vec3f, float32) {
+	return p.bounds().boundingSphere()
+}
+
+// isIntersectedByRay determines if a ray intersects with the plane.
+// It solves t = Dot(point - ro, normal) / Dot(rd, normal) and returns false
+// when the ray is parallel to the plane or when the plane is behind the ray.
+func (p Plane) isIntersectedByRay(ro, rd Vec3f) (bool, float32) {
+	denom := Dot(rd, p.normal)
+	if denom > -1e-6 && denom < 1e-6 {
+		return false, 0.0
+	}
+
+	t := Dot(Sub(p.point, ro), p.normal) / denom
+	if t < 0 {
+		return false, 0.0
+	}
+	return true, t
+}
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestPlaneFloorHitDistance(t *testing.T) {
+	floor := Plane{point: Vec3f{0, -2, 0}, normal: Vec3f{0, 1, 0}}
+	tests := []struct {
+		name   string
+		ro, rd Vec3f
+		hit    bool
+		t      float32
+	}{
+		{"straight down", Vec3f{0, 3, 0}, Vec3f{0, -1, 0}, true, 5},
+		{"oblique", Vec3f{1, 0, 1}, Vec3f{0, -1, 1}.normalized(), true, 2 * float32(1.4142135)},
+		{"pointing up", Vec3f{0, 3, 0}, Vec3f{0, 1, 0}, false, 0},
+		{"parallel", Vec3f{0, 3, 0}, Vec3f{1, 0, 0}, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit, dist := floor.isIntersectedByRay(tt.ro, tt.rd)
+			if hit != tt.hit {
+				t.Fatalf("hit = %v, want %v", hit, tt.hit)
+			}
+			if hit && !approx(dist, tt.t, 1e-5) {
+				t.Errorf("t = %v, want %v", dist, tt.t)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+// Quad represents a parallelogram going from corner along the edges u and v, such as
+// a wall or the surface of an area light. Its normal is cross(u, v), and both of its
+// sides are lit unless singleSided is set.
+type Quad struct {
+	corner, u, v Vec3f
+	Material     Materials
+	singleSided  bool
+}
+
+// at returns the point of coordinates (a, b) of the quad, (0, 0) being its corner
+// and (1, 1) the opposite one.
+func (q Quad) at(a, b float32) Vec3f {
+	return Add(q.corner, Add(q.u.mul(a), q.v.mul(b)))
+}
+
+// coordinates returns the coordinates (a, b) of the point p of the plane of the quad
+// along u and v.
+func (q Quad) coordinates(p Vec3f) (float32, float32) {
+	// w projette sur u et v même lorsqu'ils ne sont pas orthogonaux
+	n := cross(q.u, q.v)
+	w := n.mul(1 / Dot(n, n))
+	d := Sub(p, q.corner)
+	return Dot(w, cross(d, q.v)), Dot(w, cross(q.u, d))
+}
+
+// isIntersectedByRay intersects the ray with the plane of the quad and checks that the
+// point found lies within [0, 1]² along its edges.
+func (q Quad) isIntersectedByRay(ro, rd Vec3f) (bool, float32) {
+	n := cross(q.u, q.v)
+	denom := Dot(rd, n)
+	if denom > -1e-9 && denom < 1e-9 {
+		return false, 0.0
+	}
+	t := Dot(Sub(q.corner, ro), n) / denom
+	if t < 0 {
+		return false, 0.0
+	}
+	a, b := q.coordinates(Add(ro, rd.mul(t)))
+	if a < 0 || a > 1 || b < 0 || b > 1 {
+		return false, 0.0
+	}
+	return true, t
+}
+
+// surface returns the normal of the quad, flipped towards the incident ray unless it
+// is single-sided, and its material.
+func (q Quad) surface(p, rd Vec3f) (Vec3f, Materials) {
+	n := cross(q.u, q.v).normalized()
+	if !q.singleSided && Dot(n, rd) > 0 {
+		n = n.inverte()
+	}
+	return n, q.Material
+}
+
+// uvAt returns the coordinates of the point p along the edges of the quad.
+func (q Quad) uvAt(p Vec3f) Vec2f {
+	a, b := q.coordinates(p)
+	return Vec2f{a, b}
+}
+
+func (q Quad) bounds() AABB {
+	box := AABB{min: q.corner, max: q.corner}
+	for _, p := range []Vec3f{q.at(1, 0), q.at(0, 1), q.at(1, 1)} {
+		box = union(box, AABB{min: p, max: p})
+	}
+	return box
+}
+
+func (q Quad) boundingSphere() (Vec3f, float32) {
+	return q.bounds().boundingSphere()
+}
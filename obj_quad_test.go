@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestQuadEdges(t *testing.T) {
+	// Parallélogramme aux arêtes non orthogonales, dans le plan z = 5
+	q := Quad{corner: Vec3f{0, 0, 5}, u: Vec3f{2, 0, 0}, v: Vec3f{1, 1, 0}}
+	for _, tt := range []struct {
+		name string
+		a, b float32
+		hit  bool
+	}{
+		{"near the corner", 0.01, 0.01, true},
+		{"near the far corner", 0.99, 0.99, true},
+		{"just outside the u edge", 1.01, 0.5, false},
+		{"just outside the v edge", 0.5, -0.01, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := q.at(tt.a, tt.b)
+			ok, dist := q.isIntersectedByRay(Vec3f{p.x, p.y, 0}, Vec3f{0, 0, 1})
+			if ok != tt.hit {
+				t.Fatalf("hit = %v, want %v", ok, tt.hit)
+			}
+			if !ok {
+				return
+			}
+			if !approx(dist, 5, 1e-5) {
+				t.Errorf("distance = %v, want 5", dist)
+			}
+			if uv := q.uvAt(p); !approx(uv.x, tt.a, 1e-5) || !approx(uv.y, tt.b, 1e-5) {
+				t.Errorf("uv = %v, want (%v, %v)", uv, tt.a, tt.b)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import "math"
+
+// Torus represents a ring centered on center, revolving around the unit vector
+// axis. majorRadius is the distance from the center to the middle of the tube,
+// minorRadius the radius of the tube.
+type Torus struct {
+	center      Vec3f
+	axis        Vec3f
+	majorRadius float32
+	minorRadius float32
+	Material    Materials
+}
+
+// torusSteps is the number of intervals the ray is split into when looking for
+// the first sign change of the torus quartic.
+const torusSteps = 64
+
+// frame returns two unit vectors orthogonal to the axis and to each other, the
+// axis being the local y axis of the torus.
+func (to Torus) frame() (Vec3f, Vec3f, Vec3f) {
+	up := to.axis.normalized()
+	helper := Vec3f{1, 0, 0}
+	if abs32(up.x) > 0.9 {
+		helper = Vec3f{0, 0, 1}
+	}
+	right := cross(helper, up).normalized()
+	forward := cross(up, right)
+	return right, up, forward
+}
+
+// toLocal expresses v in the frame of the torus.
+func (to Torus) toLocal(v Vec3f) Vec3f {
+	right, up, forward := to.frame()
+	return Vec3f{Dot(v, right), Dot(v, up), Dot(v, forward)}
+}
+
+// quartic returns the coefficients c4..c0 of the quartic whose roots are the
+// distances at which the ray (o, d), given in the frame of the torus, hits it.
+func (to Torus) quartic(o, d Vec3f) [5]float64 {
+	R2 := float64(to.majorRadius) * float64(to.majorRadius)
+	r2 := float64(to.minorRadius) * float64(to.minorRadius)
+	ox, oy, oz := float64(o.x), float64(o.y), float64(o.z)
+	dx, dy, dz := float64(d.x), float64(d.y), float64(d.z)
+
+	m := dx*dx + dy*dy + dz*dz
+	n := ox*dx + oy*dy + oz*dz
+	k := ox*ox + oy*oy + oz*oz + R2 - r2
+
+	return [5]float64{
+		m * m,
+		4 * m * n,
+		4*n*n + 2*m*k - 4*R2*(dx*dx+dz*dz),
+		4*n*k - 8*R2*(ox*dx+oz*dz),
+		k*k - 4*R2*(ox*ox+oz*oz),
+	}
+}
+
+// isIntersectedByRay determines if a ray intersects with the torus by finding
+// the first root of its quartic. The ray is clipped to the bounding sphere of the
+// torus and sampled in torusSteps intervals; the first interval where the quartic
+// changes sign is then refined by bisection.
+func (to Torus) isIntersectedByRay(ro, rd Vec3f) (bool, float32) {
+	outer := to.majorRadius + to.minorRadius
+	bounding := Sphere{outer, to.center, nil}
+	if hit, _ := bounding.isIntersectedByRay(ro, rd); !hit {
+		return false, 0.0
+	}
+
+	o := to.toLocal(Sub(ro, to.center))
+	d := to.toLocal(rd)
+	c := to.quartic(o, d)
+	f := func(t float64) float64 {
+		return (((c[0]*t+c[1])*t+c[2])*t+c[3])*t + c[4]
+	}
+
+	// Intervalle du rayon à l'intérieur de la sphère englobante
+	b := float64(Dot(o, d))
+	a := float64(Dot(d, d))
+	delta := b*b - a*(float64(Dot(o, o))-float64(outer)*float64(outer))
+	tEnter := max((-b-math.Sqrt(delta))/a, 1e-4)
+	tExit := (-b + math.Sqrt(delta)) / a
+	if tExit <= tEnter {
+		return false, 0.0
+	}
+
+	step := (tExit - tEnter) / torusSteps
+	t0, f0 := tEnter, f(tEnter)
+	for i := 1; i <= torusSteps; i++ {
+		t1 := tEnter + float64(i)*step
+		f1 := f(t1)
+		if (f0 < 0) != (f1 < 0) {
+			for j := 0; j < 40; j++ {
+				tm := (t0 + t1) / 2
+				fm := f(tm)
+				if (f0 < 0) != (fm < 0) {
+					t1 = tm
+				} else {
+					t0, f0 = tm, fm
+				}
+			}
+			return true, float32((t0 + t1) / 2)
+		}
+		t0, f0 = t1, f1
+	}
+	return false, 0.0
+}
+
+// normalAt returns the analytic normal of the torus at the point p of its surface,
+// the gradient of the implicit equation (|p|² + R² - r²)² - 4R²(px² + pz²).
+func (to Torus) normalAt(p Vec3f) Vec3f {
+	right, up, forward := to.frame()
+	l := to.toLocal(Sub(p, to.center))
+
+	R2 := to.majorRadius * to.majorRadius
+	k := Dot(l, l) + R2 - to.minorRadius*to.minorRadius
+	grad := Sub(l.mul(4*k), Vec3f{l.x, 0, l.z}.mul(8*R2))
+
+	return Add(Add(right.mul(grad.x), up.mul(grad.y)), forward.mul(grad.z)).normalized()
+}
+
+func (to Torus) surface(p, rd Vec3f) (Vec3f, Materials) {
+	return to.normalAt(p), to.Material
+}
+
+func (to Torus) bounds() AABB {
+	outer := to.majorRadius + to.minorRadius
+	return Sphere{outer, to.center, nil}.bounds()
+}
+
+func (to Torus) boundingSphere() (Vec3f, float32) {
+	return to.bounds().boundingSphere()
+}
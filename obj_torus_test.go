@@ -0,0 +1,32 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTorusIntersection(t *testing.T) {
+	to := Torus{center: Vec3f{}, axis: Vec3f{0, 1, 0}, majorRadius: 2, minorRadius: 0.5}
+
+	if hit, _ := to.isIntersectedByRay(Vec3f{0, 5, 0}, Vec3f{0, -1, 0}); hit {
+		t.Error("ray through the hole hits the torus")
+	}
+
+	// Rayon rasant l'anneau extérieur (rayon 2.5) à 0.01 près
+	ro, rd := Vec3f{2.49, 0, -5}, Vec3f{0, 0, 1}
+	hit, dist := to.isIntersectedByRay(ro, rd)
+	if !hit {
+		t.Fatal("grazing ray misses the torus")
+	}
+	want := 5 - float32(math.Sqrt(2.5*2.5-2.49*2.49))
+	if !approx(dist, want, 1e-3) {
+		t.Errorf("t = %v, want %v", dist, want)
+	}
+	n, _ := to.surface(Add(ro, rd.mul(dist)), rd)
+	if !approx(n.norme(), 1, 1e-5) {
+		t.Errorf("normal %v has length %v, want 1", n, n.norme())
+	}
+	if n.x <= 0 {
+		t.Errorf("normal = %v, want pointing outwards (+x)", n)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+// Transformed places the primitive object in the scene through transform, the
+// primitive being defined in its own object space. Rays are brought into the object
+// space to be intersected, and normals are brought back to the world space.
+type Transformed struct {
+	object    GeometricObject
+	transform Transform
+}
+
+// local returns the ray (ro, rd) in the object space, with a unit direction, and the
+// length of rd in the object space, which converts distances between both spaces.
+func (tr Transformed) local(ro, rd Vec3f) (Vec3f, Vec3f, float32) {
+	lo := tr.transform.inv.point(ro)
+	ld := tr.transform.inv.vector(rd)
+	scale := ld.norme()
+	return lo, ld.normalized(), scale
+}
+
+// isIntersectedByRay intersects the ray brought into the object space with the
+// primitive. The distance found there is converted back to the world space.
+func (tr Transformed) isIntersectedByRay(ro, rd Vec3f) (bool, float32) {
+	lo, ld, scale := tr.local(ro, rd)
+	if scale == 0 {
+		return false, 0.0
+	}
+	isIntersected, t := tr.object.isIntersectedByRay(lo, ld)
+	if !isIntersected {
+		return false, 0.0
+	}
+	return true, t / scale
+}
+
+func (tr Transformed) surface(p, rd Vec3f) (Vec3f, Materials) {
+	n, m := tr.object.surface(tr.transform.inv.point(p), tr.transform.inv.vector(rd))
+	return tr.transform.normal(n), m
+}
+
+// bounds returns the box containing the eight transformed corners of the bounds of
+// the primitive, or infiniteAABB if the primitive is unbounded.
+func (tr Transformed) bounds() AABB {
+	b := tr.object.bounds()
+	if !b.isFinite() {
+		return infiniteAABB
+	}
+	var res AABB
+	for i := 0; i < 8; i++ {
+		corner := b.min
+		if i&1 != 0 {
+			corner.x = b.max.x
+		}
+		if i&2 != 0 {
+			corner.y = b.max.y
+		}
+		if i&4 != 0 {
+			corner.z = b.max.z
+		}
+		p := tr.transform.point(corner)
+		if i == 0 {
+			res = AABB{min: p, max: p}
+			continue
+		}
+		res = union(res, AABB{min: p, max: p})
+	}
+	return res
+}
+
+func (tr Transformed) boundingSphere() (Vec3f, float32) {
+	return tr.bounds().boundingSphere()
+}
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestTranslatedSphere(t *testing.T) {
+	scene := Scene{}
+	sphere := Sphere{1, Vec3f{}, Lambert{Vec3f{1, 1, 1}}}
+	scene.addElement(Transformed{sphere, translation(Vec3f{5, 0, 0})})
+
+	hit, ok := scene.intersect(Vec3f{5, 0, -5}, Vec3f{0, 0, 1})
+	if !ok {
+		t.Fatal("ray towards the translated sphere missed it")
+	}
+	// Même touche que sur la sphère d'origine, décalée de (5, 0, 0)
+	if !approx(hit.t, 4, 1e-5) || !approxVec(hit.point, Vec3f{5, 0, -1}, 1e-5) {
+		t.Errorf("hit at t = %v, point %v, want t = 4 at (5, 0, -1)", hit.t, hit.point)
+	}
+	if !approxVec(hit.normal, Vec3f{0, 0, -1}, 1e-5) {
+		t.Errorf("normal = %v, want (0, 0, -1)", hit.normal)
+	}
+	if _, ok := scene.intersect(Vec3f{0, 0, -5}, Vec3f{0, 0, 1}); ok {
+		t.Error("ray towards the untransformed position hit the sphere")
+	}
+}
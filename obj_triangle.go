@@ -0,0 +1,100 @@
+package main
+
+// Triangle represents a triangle defined by its three vertices. When the vertex
+// normals n0, n1 and n2 are set, they are interpolated over the triangle to give
+// a smooth shading normal instead of the flat geometric one. Both of its sides are
+// lit unless singleSided is set: only its front side, where its vertices turn
+// counter-clockwise, is lit then.
+type Triangle struct {
+	v0, v1, v2  Vec3f
+	Material    Materials
+	n0, n1, n2  Vec3f
+	singleSided bool
+}
+
+// normal returns the geometric normal of the triangle, following the
+// counter-clockwise winding order of its vertices.
+func (tr Triangle) normal() Vec3f {
+	return cross(Sub(tr.v1, tr.v0), Sub(tr.v2, tr.v0)).normalized()
+}
+
+// smooth reports whether the triangle has vertex normals.
+func (tr Triangle) smooth() bool {
+	return tr.n0 != (Vec3f{}) || tr.n1 != (Vec3f{}) || tr.n2 != (Vec3f{})
+}
+
+// barycentric returns the barycentric coordinates (u, v) of the point p of the
+// triangle, the weights of v1 and v2. They solve p - v0 = u*e1 + v*e2 projected on
+// the edges e1 and e2, by Cramer's rule on the dot products of the edges.
+func (tr Triangle) barycentric(p Vec3f) (float32, float32) {
+	e1 := Sub(tr.v1, tr.v0)
+	e2 := Sub(tr.v2, tr.v0)
+	w := Sub(p, tr.v0)
+	d00, d01, d11 := Dot(e1, e1), Dot(e1, e2), Dot(e2, e2)
+	d20, d21 := Dot(w, e1), Dot(w, e2)
+	denom := d00*d11 - d01*d01
+	if denom == 0 {
+		return 0, 0
+	}
+	return (d11*d20 - d01*d21) / denom, (d00*d21 - d01*d20) / denom
+}
+
+// surface returns the geometric normal, or the interpolated vertex normals when the
+// triangle has some, flipped on the side of the incident ray unless it is single-sided.
+func (tr Triangle) surface(p, rd Vec3f) (Vec3f, Materials) {
+	geometric := tr.normal()
+	n := geometric
+	if tr.smooth() {
+		u, v := tr.barycentric(p)
+		n = Add(Add(tr.n0.mul(1-u-v), tr.n1.mul(u)), tr.n2.mul(v)).normalized()
+	}
+	// Le côté vu est donné par la normale géométrique, la normale interpolée pouvant
+	// passer de l'autre côté près des bords
+	if !tr.singleSided && Dot(geometric, rd) > 0 {
+		n = n.inverte()
+	}
+	return n, tr.Material
+}
+
+func (tr Triangle) bounds() AABB {
+	return AABB{
+		min: Vec3f{min(tr.v0.x, tr.v1.x, tr.v2.x), min(tr.v0.y, tr.v1.y, tr.v2.y), min(tr.v0.z, tr.v1.z, tr.v2.z)},
+		max: Vec3f{max(tr.v0.x, tr.v1.x, tr.v2.x), max(tr.v0.y, tr.v1.y, tr.v2.y), max(tr.v0.z, tr.v1.z, tr.v2.z)},
+	}
+}
+
+func (tr Triangle) boundingSphere() (Vec3f, float32) {
+	return tr.bounds().boundingSphere()
+}
+
+// isIntersectedByRay determines if a ray intersects with the triangle
+// using the Möller–Trumbore algorithm.
+func (tr Triangle) isIntersectedByRay(ro, rd Vec3f) (bool, float32) {
+	e1 := Sub(tr.v1, tr.v0)
+	e2 := Sub(tr.v2, tr.v0)
+
+	p := cross(rd, e2)
+	det := Dot(e1, p)
+	if det > -1e-6 && det < 1e-6 {
+		return false, 0.0
+	}
+	invDet := 1 / det
+
+	s := Sub(ro, tr.v0)
+	u := Dot(s, p) * invDet
+	if u < 0 || u > 1 {
+		return false, 0.0
+	}
+
+	q := cross(s, e1)
+	v := Dot(rd, q) * invDet
+	if v < 0 || u+v > 1 {
+		return false, 0.0
+	}
+
+	t := Dot(e2, q) * invDet
+	if t < 0 {
+		return false, 0.0
+	}
+	return true, t
+}
@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestTriangleSharedEdgeNormals(t *testing.T) {
+	// Carré fait de deux triangles partageant la diagonale a-c, chaque sommet ayant
+	// sa propre normale
+	a, b, c, d := Vec3f{0, 0, 0}, Vec3f{1, 0, 0}, Vec3f{1, 1, 0}, Vec3f{0, 1, 0}
+	na := Vec3f{-1, -1, 2}.normalized()
+	nb := Vec3f{1, -1, 2}.normalized()
+	nc := Vec3f{1, 1, 2}.normalized()
+	nd := Vec3f{-1, 1, 2}.normalized()
+	m := Lambert{Vec3f{1, 1, 1}}
+	lower := Triangle{v0: a, v1: b, v2: c, Material: m, n0: na, n1: nb, n2: nc}
+	upper := Triangle{v0: a, v1: c, v2: d, Material: m, n0: na, n1: nc, n2: nd}
+
+	rd := Vec3f{0, 0, -1}
+	for _, s := range []float32{0, 0.25, 0.5, 0.9} {
+		p := Lerp(a, c, s)
+		nLower, _ := lower.surface(p, rd)
+		nUpper, _ := upper.surface(p, rd)
+		if !approxVec(nLower, nUpper, 1e-6) {
+			t.Errorf("normals at %v = %v and %v, want the same on the shared edge", p, nLower, nUpper)
+		}
+		// Seules les normales des extrémités de l'arête comptent
+		if want := Lerp(na, nc, s).normalized(); !approxVec(nLower, want, 1e-6) {
+			t.Errorf("normal at %v = %v, want %v", p, nLower, want)
+		}
+	}
+}
+
+func TestTriangleDoubleSided(t *testing.T) {
+	// Triangle tourné vers -z, vu et éclairé par-derrière
+	front := Triangle{v0: Vec3f{-1, -1, 0}, v1: Vec3f{0, 1, 0}, v2: Vec3f{1, -1, 0}, Material: Lambert{Vec3f{1, 1, 1}}}
+	if n := front.normal(); !approxVec(n, Vec3f{0, 0, -1}, 1e-6) {
+		t.Fatalf("normal = %v, want (0, 0, -1)", n)
+	}
+	back := func(tr Triangle) Vec3f {
+		scene := Scene{}
+		scene.addElement(tr)
+		scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{0, 0, 5}})
+		return shade(t, scene, Vec3f{0, 0, 5}, Vec3f{0, 0, -1})
+	}
+	if got := back(front); got.x <= 0 {
+		t.Errorf("back of a double-sided triangle = %v, want lit", got)
+	}
+	singleSided := front
+	singleSided.singleSided = true
+	if got := back(singleSided); got != (Vec3f{}) {
+		t.Errorf("back of a single-sided triangle = %v, want black", got)
+	}
+}
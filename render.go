@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/rand"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// RenderOptions controls how a Scene is rendered. Zero fields take their default value.
+type RenderOptions struct {
+	// Nombre de rayons par pixel (1 par défaut)
+	samples int
+	// Nombre maximal de rebonds des rayons réfléchis ou réfractés (defaultMaxDepth par défaut)
+	maxDepth int
+	// Nombre maximal de rayons tracés par pixel, primaires, secondaires, d'ombre et
+	// d'occlusion ambiante, tous échantillons confondus (illimité si 0) : le pixel
+	// garde alors la couleur accumulée jusque-là
+	maxRaysPerPixel int
+	// Nombre de goroutines de rendu (runtime.NumCPU() par défaut)
+	threads int
+	// Taille des tuiles carrées réparties entre les goroutines (defaultTileSize par défaut)
+	tileSize int
+	// Graine des sources aléatoires de l'échantillonnage (anticrénelage, profondeur de champ,
+	// lumières étendues, occlusion ambiante) : une même graine donne la même image
+	seed int64
+	// Roulette russe pour les rayons plus profonds que maxDepth, qui ne sont alors plus
+	// arrêtés systématiquement : moins d'énergie perdue, au prix d'un peu de bruit
+	roulette bool
+	// Mode de débogage remplaçant les matériaux : "normals", "depth" ou "lights" (voir debugColor) ;
+	// désactivé si vide
+	debug string
+	// Décalage de l'origine des rayons partant d'une surface (surfaceEpsilon par
+	// défaut, aucun si négatif)
+	epsilon float32
+	// Remplit aussi le depthBuffer de l'image rendue
+	depth bool
+	// Appelée après chaque tuile rendue avec la fraction de l'image terminée ;
+	// les appels sont sérialisés même lorsque le rendu est parallèle
+	progress func(fraction float32)
+}
+
+// withDefaults returns a copy of the options where unset fields take their default value.
+func (opts RenderOptions) withDefaults() RenderOptions {
+	if opts.samples <= 0 {
+		opts.samples = 1
+	}
+	if opts.maxDepth <= 0 {
+		opts.maxDepth = defaultMaxDepth
+	}
+	if opts.threads <= 0 {
+		opts.threads = runtime.NumCPU()
+	}
+	if opts.tileSize <= 0 {
+		opts.tileSize = defaultTileSize
+	}
+	if opts.epsilon == 0 {
+		opts.epsilon = surfaceEpsilon
+	}
+	return opts
+}
+
+// primaryContext returns the context of a ray leaving the camera.
+func (opts RenderOptions) primaryContext(rng *rand.Rand) rayContext {
+	return rayContext{
+		maxDepth:   opts.maxDepth,
+		rng:        rng,
+		roulette:   opts.roulette,
+		throughput: 1,
+		debug:      opts.debug,
+		epsilon:    max(opts.epsilon, 0),
+	}
+}
+
+// tileSeed returns the seed of the random source of the tile of index i for the render
+// seed, mixing both (SplitMix64) so that neighbouring seeds give unrelated sequences.
+func tileSeed(seed int64, i int) int64 {
+	z := uint64(seed) + uint64(i+1)*0x9E3779B97F4A7C15
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return int64(z ^ (z >> 31))
+}
+
+// Accumulator averages the color samples of a pixel. The sum is kept in double
+// precision so that adding thousands of samples, as progressive rendering does,
+// loses no precision.
+type Accumulator struct {
+	sum   [3]float64
+	count int
+}
+
+// add adds the sample c.
+func (a *Accumulator) add(c Vec3f) {
+	a.sum[0] += float64(c.x)
+	a.sum[1] += float64(c.y)
+	a.sum[2] += float64(c.z)
+	a.count++
+}
+
+// mean returns the average of the samples added so far, black if there is none.
+func (a Accumulator) mean() Vec3f {
+	if a.count == 0 {
+		return Vec3f{}
+	}
+	n := float64(a.count)
+	return Vec3f{float32(a.sum[0] / n), float32(a.sum[1] / n), float32(a.sum[2] / n)}
+}
+
+// stratifiedOffset returns the offset in [0, 1)² within the pixel of its sample of
+// index i out of n. When n is a perfect square k*k, the pixel is divided into a
+// k × k grid and the sample is jittered within its own cell, which spreads the
+// samples more evenly than pure random jitter; otherwise the offset is uniform.
+func stratifiedOffset(i, n int, rng *rand.Rand) (float32, float32) {
+	dx, dy := rng.Float32(), rng.Float32()
+	k := int(math.Sqrt(float64(n)))
+	if k <= 1 || k*k != n {
+		return dx, dy
+	}
+	// Cellule de l'échantillon, parcourue ligne par ligne
+	cell := i % n
+	return (float32(cell%k) + dx) / float32(k), (float32(cell/k) + dy) / float32(k)
+}
+
+// defaultTileSize is the size in pixels of the tiles when the render options do not set one.
+const defaultTileSize = 32
+
+// tile is the rectangle of pixels [x0, x1) × [y0, y1) of an image.
+type tile struct {
+	x0, y0, x1, y1 int
+}
+
+func (t tile) area() int {
+	return (t.x1 - t.x0) * (t.y1 - t.y0)
+}
+
+// splitTiles divides a width × height image into square tiles of size pixels, row
+// by row. The tiles of the last column and row are cropped to the image.
+func splitTiles(width, height, size int) []tile {
+	var tiles []tile
+	for y := 0; y < height; y += size {
+		for x := 0; x < width; x += size {
+			tiles = append(tiles, tile{x, y, min(x+size, width), min(y+size, height)})
+		}
+	}
+	return tiles
+}
+
+// RenderStats summarizes the work done by a render.
+type RenderStats struct {
+	elapsed time.Duration
+	// Rayons partant de la caméra, rayons d'ombre vers les lumières et tests
+	// d'intersection rayon-objet (occlusion ambiante comprise)
+	primaryRays       int64
+	shadowRays        int64
+	intersectionTests int64
+}
+
+func (st RenderStats) String() string {
+	return fmt.Sprintf("rendered in %v: %d primary rays, %d shadow rays, %d intersection tests",
+		st.elapsed.Round(time.Millisecond), st.primaryRays, st.shadowRays, st.intersectionTests)
+}
+
+// renderCounters counts the rays of a render. The goroutines of the render share it
+// through the scene, so its counters are atomic; its methods do nothing on a nil
+// receiver, outside of Render.
+type renderCounters struct {
+	primaryRays, shadowRays, intersectionTests atomic.Int64
+}
+
+func (c *renderCounters) addPrimaryRays(n int) {
+	if c != nil {
+		c.primaryRays.Add(int64(n))
+	}
+}
+
+func (c *renderCounters) addShadowRays(n int) {
+	if c != nil {
+		c.shadowRays.Add(int64(n))
+	}
+}
+
+func (c *renderCounters) addIntersectionTests(n int) {
+	if c != nil && n > 0 {
+		c.intersectionTests.Add(int64(n))
+	}
+}
+
+// Render renders the scene seen from camera into a new image of the given size, and
+// returns it with the statistics of the render. The returned image holds linear
+// colors: tone mapping and gamma correction are left to the caller. It returns an
+// error without rendering anything if the scene or the camera are not valid.
+func (s Scene) Render(camera Camera, width, height int, opts RenderOptions) (Image, RenderStats, error) {
+	return s.RenderRegion(camera, width, height, 0, 0, width, height, opts)
+}
+
+// RenderRegion renders like Render only the pixels of the rectangle [x0, x1) × [y0, y1)
+// of the image, the others staying black (and at 0 in the depth buffer). The rectangle
+// must lie within the image and not be empty. When its corners are multiples of the
+// tile size, its pixels are the same as in a render of the whole image with the same
+// options, so that an image can be split between several machines.
+func (s Scene) RenderRegion(camera Camera, width, height, x0, y0, x1, y1 int, opts RenderOptions) (Image, RenderStats, error) {
+	image, err := s.newImage(camera, width, height, opts)
+	if err != nil {
+		return Image{}, RenderStats{}, err
+	}
+	if x0 < 0 || y0 < 0 || x1 > width || y1 > height || x0 >= x1 || y0 >= y1 {
+		return Image{}, RenderStats{}, fmt.Errorf("invalid region [%d, %d) x [%d, %d) of a %dx%d image", x0, x1, y0, y1, width, height)
+	}
+	start := time.Now()
+	s.counters = &renderCounters{}
+	renderFrame(image, camera, s, opts, tile{x0, y0, x1, y1})
+	stats := RenderStats{
+		elapsed:           time.Since(start),
+		primaryRays:       s.counters.primaryRays.Load(),
+		shadowRays:        s.counters.shadowRays.Load(),
+		intersectionTests: s.counters.intersectionTests.Load(),
+	}
+	return image, stats, nil
+}
+
+// newImage checks that the scene can be rendered from camera and returns the
+// black image of the given size to render it into.
+func (s Scene) newImage(camera Camera, width, height int, opts RenderOptions) (Image, error) {
+	if err := s.Validate(); err != nil {
+		return Image{}, fmt.Errorf("invalid scene: %v", err)
+	}
+	if err := camera.Validate(); err != nil {
+		return Image{}, fmt.Errorf("invalid camera: %v", err)
+	}
+	if width <= 0 || height <= 0 {
+		return Image{}, fmt.Errorf("invalid image size %dx%d", width, height)
+	}
+
+	image := Image{frameBuffer: make([]Vec3f, width*height), width: width, height: height}
+	if opts.depth {
+		image.depthBuffer = make([]float32, width*height)
+	}
+	return image, nil
+}
+
+// RenderProgressive renders the scene in passes passes of one sample per pixel,
+// calling cb after each pass with the average of the samples rendered so far, so that
+// a preview sharpens over time. opts.samples is ignored and opts.progress is called
+// after each pass. The image given to cb is rewritten by the next pass: cb may develop
+// it in place but must copy it to keep it. The samples are jittered as in a render of
+// passes samples, so that the final image is the same as that render with the same seed.
+func (s Scene) RenderProgressive(camera Camera, width, height, passes int, opts RenderOptions, cb func(Image)) error {
+	image, err := s.newImage(camera, width, height, opts)
+	if err != nil {
+		return err
+	}
+	opts = opts.withDefaults()
+	// Les passes se répartissent les cellules de l'échantillonnage stratifié
+	opts.samples = passes
+	progress := opts.progress
+	opts.progress = nil
+
+	state := newFrameState(image, opts, jitters(camera, passes), tile{0, 0, width, height})
+	for pass := 0; pass < passes; pass++ {
+		renderPasses(image, camera, s, opts, state, 1)
+		if progress != nil {
+			progress(float32(pass+1) / float32(passes))
+		}
+		if cb != nil {
+			cb(image)
+		}
+	}
+	return nil
+}
+
+// RenderToImage renders the scene with the default options and returns it as a
+// standard 8-bit image, without touching the disk. As with save, the linear colors
+// are only clamped: tone mapping and gamma correction must be applied beforehand
+// through Render when needed. It fails like Render on an invalid scene or camera.
+func (s Scene) RenderToImage(camera Camera, width, height int) (*image.RGBA, error) {
+	img, _, err := s.Render(camera, width, height, RenderOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return img.toRGBA(), nil
+}
+
+// RenderMask returns the coverage mask of the object of identifier id (as returned by
+// addElement) in a width × height image: a pixel is set when the object is the nearest
+// hit of the ray going through its center, following the same tie-break as rendering.
+// It returns nil for an invalid size.
+func (s Scene) RenderMask(camera Camera, width, height int, id int) []bool {
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+	mask := make([]bool, width*height)
+	pixelRay := cameraRays(camera, width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			ro, rd := pixelRay(x, y, 0.5, 0.5, nil)
+			hit := s.nearestHit(ro, rd)
+			mask[y*width+x] = hit.object != nil && hit.id == id
+		}
+	}
+	return mask
+}
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// sphereScene returns a scene with a red Lambertian sphere lit from the camera, and
+// the camera looking at it.
+func sphereScene() (Scene, Camera) {
+	scene := Scene{}
+	scene.addElement(Sphere{1, Vec3f{0, 0, 5}, Lambert{Vec3f{1, 0, 0}}})
+	scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{}})
+	camera, _ := NewCamera(Vec3f{}, Vec3f{0, 0, 1}, Vec3f{0, 1, 0}, 40)
+	return scene, camera
+}
+
+func TestRenderCenterPixel(t *testing.T) {
+	scene, camera := sphereScene()
+	img, _, err := scene.Render(camera, 11, 11, RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Au centre, la sphère fait face à la lumière : diffuse kd/π
+	want := Vec3f{1 / 3.14, 0, 0}
+	if got := img.frameBuffer[5*11+5]; !approxVec(got, want, 1e-3) {
+		t.Errorf("center pixel = %v, want the sphere color %v", got, want)
+	}
+	if got := img.frameBuffer[0]; got != (Vec3f{}) {
+		t.Errorf("corner pixel = %v, want the black background", got)
+	}
+}
+
+func TestRenderProgressMonotonic(t *testing.T) {
+	scene, camera := sphereScene()
+	var fractions []float32
+	opts := RenderOptions{threads: 4, tileSize: 4, progress: func(f float32) {
+		fractions = append(fractions, f)
+	}}
+	if _, _, err := scene.Render(camera, 30, 20, opts); err != nil {
+		t.Fatal(err)
+	}
+	// 8 × 5 tuiles, la dernière colonne et la dernière ligne étant rognées
+	if len(fractions) != 40 {
+		t.Errorf("progress called %d times, want once per tile (40)", len(fractions))
+	}
+	for i := 1; i < len(fractions); i++ {
+		if fractions[i] <= fractions[i-1] {
+			t.Errorf("fraction %d = %v after %v, want increasing", i, fractions[i], fractions[i-1])
+		}
+	}
+	if len(fractions) == 0 || fractions[len(fractions)-1] != 1 {
+		t.Errorf("fractions = %v, want them to end at 1", fractions)
+	}
+}
+
+func TestSplitTilesCoverImage(t *testing.T) {
+	for _, tc := range []struct {
+		width, height, size int
+	}{
+		{64, 64, 32},
+		{70, 45, 32},
+		{5, 3, 8},
+		{1, 1, 1},
+	} {
+		t.Run(fmt.Sprintf("%dx%d/%d", tc.width, tc.height, tc.size), func(t *testing.T) {
+			// Chaque pixel doit appartenir à une et une seule tuile
+			covered := make([]int, tc.width*tc.height)
+			area := 0
+			for _, tl := range splitTiles(tc.width, tc.height, tc.size) {
+				if tl.x1-tl.x0 > tc.size || tl.y1-tl.y0 > tc.size {
+					t.Errorf("tile %v is larger than %d", tl, tc.size)
+				}
+				area += tl.area()
+				for y := tl.y0; y < tl.y1; y++ {
+					for x := tl.x0; x < tl.x1; x++ {
+						covered[y*tc.width+x]++
+					}
+				}
+			}
+			if area != tc.width*tc.height {
+				t.Errorf("tiles cover %d pixels, want %d", area, tc.width*tc.height)
+			}
+			for i, n := range covered {
+				if n != 1 {
+					t.Errorf("pixel (%d, %d) is covered %d times, want once", i%tc.width, i/tc.width, n)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderToImage(t *testing.T) {
+	scene, camera := sphereScene()
+	img, err := scene.RenderToImage(camera, 11, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.Bounds(); got != image.Rect(0, 0, 11, 7) {
+		t.Errorf("bounds = %v, want (0,0)-(11,7)", got)
+	}
+	// Centre : la sphère rouge de diffuse 1/π, seulement bornée, sans gamma
+	want := color.RGBA{clampColor(Vec3f{1 / 3.14, 0, 0}).r, 0, 0, 255}
+	if got := img.RGBAAt(5, 3); got != want {
+		t.Errorf("center pixel = %v, want %v", got, want)
+	}
+
+	if _, err := scene.RenderToImage(camera, 0, 7); err == nil {
+		t.Error("RenderToImage of a 0x7 image succeeded, want an error")
+	}
+}
+
+func TestSphereStaysCircular(t *testing.T) {
+	scene := Scene{}
+	id := scene.addElement(Sphere{0.5, Vec3f{0, 0, 5}, Lambert{Vec3f{1, 0, 0}}})
+	camera, _ := NewCamera(Vec3f{}, Vec3f{0, 0, 1}, Vec3f{0, 1, 0}, 40)
+	for _, size := range [][2]int{{200, 100}, {100, 200}} {
+		width, height := size[0], size[1]
+		t.Run(fmt.Sprintf("%dx%d", width, height), func(t *testing.T) {
+			// Largeur et hauteur de la silhouette en pixels
+			mask := scene.RenderMask(camera, width, height, id)
+			var cols, rows int
+			for x := 0; x < width; x++ {
+				if mask[(height/2)*width+x] {
+					cols++
+				}
+			}
+			for y := 0; y < height; y++ {
+				if mask[y*width+width/2] {
+					rows++
+				}
+			}
+			if cols == 0 || abs32(float32(cols-rows)) > 2 {
+				t.Errorf("silhouette is %d pixels wide and %d pixels high, want a circle", cols, rows)
+			}
+		})
+	}
+}
+
+func TestRenderSeed(t *testing.T) {
+	scene, camera := sphereScene()
+	render := func(seed int64) []Vec3f {
+		img, _, err := scene.Render(camera, 16, 16, RenderOptions{samples: 4, seed: seed})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return img.frameBuffer
+	}
+	// L'anticrénelage tire ses décalages de la graine : le bord de la sphère en dépend
+	if a, b := render(1), render(1); !slices.Equal(a, b) {
+		t.Error("two renders with the same seed differ")
+	}
+	if a, b := render(1), render(2); slices.Equal(a, b) {
+		t.Error("renders with the seeds 1 and 2 are identical")
+	}
+}
+
+func TestDebugNormalsCenter(t *testing.T) {
+	scene, camera := sphereScene()
+	img, _, err := scene.Render(camera, 11, 11, RenderOptions{debug: "normals"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Au centre, la normale (0, 0, -1) fait face à la caméra
+	want := Vec3f{0.5, 0.5, 0}
+	if got := img.frameBuffer[5*11+5]; !approxVec(got, want, 1e-3) {
+		t.Errorf("center pixel = %v, want the normal color %v", got, want)
+	}
+}
+
+func TestDepthBufferOrder(t *testing.T) {
+	scene := Scene{}
+	near := scene.addElement(Sphere{1, Vec3f{-1.5, 0, 6}, Lambert{Vec3f{1, 1, 1}}})
+	far := scene.addElement(Sphere{1, Vec3f{1.5, 0, 10}, Lambert{Vec3f{1, 1, 1}}})
+	scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{}})
+	camera, _ := NewCamera(Vec3f{}, Vec3f{0, 0, 1}, Vec3f{0, 1, 0}, 40)
+	const width, height = 40, 20
+	img, _, err := scene.Render(camera, width, height, RenderOptions{depth: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Profondeur d'un pixel couvert par chaque sphère
+	depthOf := func(id int) float32 {
+		for i, covered := range scene.RenderMask(camera, width, height, id) {
+			if covered {
+				return img.depthBuffer[i]
+			}
+		}
+		t.Fatalf("sphere %d is not visible", id)
+		return 0
+	}
+	if dNear, dFar := depthOf(near), depthOf(far); dNear >= dFar {
+		t.Errorf("depth of the closer sphere = %v, want less than %v for the farther one", dNear, dFar)
+	}
+	if d := img.depthBuffer[0]; !math.IsInf(float64(d), 1) {
+		t.Errorf("depth of the background corner = %v, want +Inf", d)
+	}
+}
+
+func TestAccumulator(t *testing.T) {
+	var acc Accumulator
+	if got := acc.mean(); got != (Vec3f{}) {
+		t.Errorf("mean of no sample = %v, want black", got)
+	}
+	// Une couleur constante, même sommée un grand nombre de fois, reste exacte
+	c := Vec3f{0.1, 0.2, 0.3}
+	for i := 0; i < 100000; i++ {
+		acc.add(c)
+	}
+	if got := acc.mean(); got != c {
+		t.Errorf("mean of a constant color = %v, want exactly %v", got, c)
+	}
+
+	// La moyenne suit les nouveaux échantillons
+	var ramp Accumulator
+	for i := 1; i <= 4; i++ {
+		ramp.add(Vec3f{float32(i), 0, 0})
+		if want := float32(i+1) / 2; ramp.mean().x != want {
+			t.Errorf("mean after %d samples = %v, want %v", i, ramp.mean().x, want)
+		}
+	}
+}
+
+func TestRenderProgressiveMatchesRender(t *testing.T) {
+	scene, camera := sphereScene()
+	for _, passes := range []int{1, 4} {
+		t.Run(fmt.Sprint(passes), func(t *testing.T) {
+			opts := RenderOptions{seed: 3, tileSize: 4}
+			calls := 0
+			var last []Vec3f
+			err := scene.RenderProgressive(camera, 16, 16, passes, opts, func(img Image) {
+				calls++
+				last = slices.Clone(img.frameBuffer)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if calls != passes {
+				t.Errorf("callback called %d times, want %d", calls, passes)
+			}
+
+			opts.samples = passes
+			want, _, err := scene.Render(camera, 16, 16, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(last, want.frameBuffer) {
+				t.Errorf("image after %d passes differs from a render of %d samples", passes, passes)
+			}
+		})
+	}
+}
+
+func TestStratifiedOffsetQuadrants(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	// 4 échantillons : une grille 2 × 2, parcourue ligne par ligne
+	var quadrants [2][2]int
+	for i := 0; i < 4; i++ {
+		dx, dy := stratifiedOffset(i, 4, rng)
+		if dx < 0 || dx >= 1 || dy < 0 || dy >= 1 {
+			t.Fatalf("offset %d = (%v, %v), want in [0, 1)²", i, dx, dy)
+		}
+		qx, qy := int(dx*2), int(dy*2)
+		if qx != i%2 || qy != i/2 {
+			t.Errorf("offset %d = (%v, %v) in the quadrant (%d, %d), want (%d, %d)", i, dx, dy, qx, qy, i%2, i/2)
+		}
+		quadrants[qy][qx]++
+	}
+	if quadrants != [2][2]int{{1, 1}, {1, 1}} {
+		t.Errorf("samples per quadrant = %v, want one in each", quadrants)
+	}
+}
+
+func TestRenderStatsPrimaryRays(t *testing.T) {
+	scene, camera := sphereScene()
+	_, stats, err := scene.Render(camera, 10, 10, RenderOptions{tileSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.primaryRays != 100 {
+		t.Errorf("primary rays = %d, want one per pixel (100)", stats.primaryRays)
+	}
+	// Un rayon d'ombre par pixel touchant la sphère, éclairée par une seule lumière
+	if stats.shadowRays <= 0 || stats.shadowRays >= 100 {
+		t.Errorf("shadow rays = %d, want one per pixel of the sphere", stats.shadowRays)
+	}
+
+	// Le tampon de profondeur ne change pas les statistiques
+	_, depthStats, err := scene.Render(camera, 10, 10, RenderOptions{tileSize: 4, depth: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	depthStats.elapsed = stats.elapsed
+	if depthStats != stats {
+		t.Errorf("stats with a depth buffer = %v, want %v as without", depthStats, stats)
+	}
+}
+
+func TestRenderRegionOnlyFillsRegion(t *testing.T) {
+	scene, camera := sphereScene()
+	// Fond blanc : tous les pixels rendus sont non noirs
+	scene.setBackground(Vec3f{1, 1, 1})
+	const width, height = 16, 16
+	region := tile{4, 8, 12, 16}
+	img, _, err := scene.RenderRegion(camera, width, height, region.x0, region.y0, region.x1, region.y1, RenderOptions{tileSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, _, err := scene.Render(camera, width, height, RenderOptions{tileSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			got := img.frameBuffer[y*width+x]
+			inside := x >= region.x0 && x < region.x1 && y >= region.y0 && y < region.y1
+			if inside && got != full.frameBuffer[y*width+x] {
+				t.Errorf("pixel (%d, %d) = %v, want %v as in the full render", x, y, got, full.frameBuffer[y*width+x])
+			}
+			if !inside && got != (Vec3f{}) {
+				t.Errorf("pixel (%d, %d) outside the region = %v, want black", x, y, got)
+			}
+		}
+	}
+
+	if _, _, err := scene.RenderRegion(camera, width, height, 8, 0, 20, 4, RenderOptions{}); err == nil {
+		t.Error("RenderRegion of a region exceeding the image succeeded, want an error")
+	}
+}
+
+// TestRenderConcurrentShadows renders a scene with shadows, soft shadows and ambient
+// occlusion on many goroutines; run it with -race to check that they share nothing.
+func TestRenderConcurrentShadows(t *testing.T) {
+	scene := Scene{}
+	scene.setAmbient(Vec3f{0.1, 0.1, 0.1})
+	scene.setAmbientOcclusion(4, 2)
+	scene.addElement(Plane{point: Vec3f{0, -1, 0}, normal: Vec3f{0, 1, 0}, Material: Lambert{Vec3f{1, 1, 1}}})
+	scene.addElement(Sphere{1, Vec3f{0, 0, 5}, Phong{Vec3f{1, 0, 0}, Vec3f{1, 0, 0}, Vec3f{1, 1, 1}, 10}})
+	scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{3, 5, 0}})
+	scene.addLight(AreaLight{corner: Vec3f{-3, 5, 4}, u: Vec3f{1, 0, 0}, v: Vec3f{0, 0, 1}, color: Vec3f{0.5, 0.5, 0.5}, samples: 4})
+	camera, _ := NewCamera(Vec3f{0, 1, -2}, Vec3f{0, 0, 5}, Vec3f{0, 1, 0}, 50)
+
+	var reference []Vec3f
+	for _, threads := range []int{1, 8, 32, 32} {
+		img, _, err := scene.Render(camera, 48, 32, RenderOptions{samples: 2, threads: threads, tileSize: 8, seed: 7})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reference == nil {
+			reference = img.frameBuffer
+		} else if !slices.Equal(img.frameBuffer, reference) {
+			t.Errorf("render on %d goroutines differs from the render on one", threads)
+		}
+	}
+}
+
+func TestShadowAcne(t *testing.T) {
+	// Sol entièrement éclairé sous un ciel blanc : tout pixel noir est de l'acné
+	scene := Scene{}
+	scene.setBackground(Vec3f{1, 1, 1})
+	scene.addElement(Plane{point: Vec3f{}, normal: Vec3f{0, 1, 0}, Material: Lambert{Vec3f{1, 1, 1}}})
+	scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{0, 5, 5}})
+	camera, _ := NewCamera(Vec3f{0, 2, 0}, Vec3f{0, 0, 5}, Vec3f{0, 1, 0}, 60)
+
+	blackPixels := func(epsilon float32) int {
+		img, _, err := scene.Render(camera, 32, 32, RenderOptions{epsilon: epsilon})
+		if err != nil {
+			t.Fatal(err)
+		}
+		black := 0
+		for _, c := range img.frameBuffer {
+			if c == (Vec3f{}) {
+				black++
+			}
+		}
+		return black
+	}
+	// Un epsilon négatif désactive le décalage des rayons d'ombre
+	if acne := blackPixels(-1); acne < 32 {
+		t.Errorf("%d black pixels without offset, want shadow acne", acne)
+	}
+	if acne := blackPixels(0); acne != 0 {
+		t.Errorf("%d black pixels with the default offset, want none", acne)
+	}
+}
+
+func TestCoincidentSpheresOrder(t *testing.T) {
+	red := Sphere{1, Vec3f{0, 0, 5}, Lambert{Vec3f{1, 0, 0}}}
+	blue := Sphere{1, Vec3f{0, 0, 5}, Lambert{Vec3f{0, 0, 1}}}
+	camera, _ := NewCamera(Vec3f{}, Vec3f{0, 0, 1}, Vec3f{0, 1, 0}, 40)
+	for _, build := range []struct {
+		name  string
+		build func(*Scene)
+	}{
+		{"linear", func(*Scene) {}},
+		{"bvh", (*Scene).buildBVH},
+		{"grid", (*Scene).buildGrid},
+	} {
+		t.Run(build.name, func(t *testing.T) {
+			centers := map[Vec3f]bool{}
+			for _, order := range [][]Sphere{{red, blue}, {blue, red}} {
+				scene := Scene{}
+				scene.addLight(Light{color: Vec3f{1, 1, 1}, position: Vec3f{}})
+				for _, s := range order {
+					scene.addElement(s)
+				}
+				build.build(&scene)
+				img, _, err := scene.Render(camera, 9, 9, RenderOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				centers[img.frameBuffer[4*9+4]] = true
+			}
+			// L'égalité est tranchée par la clé des sphères : la même l'emporte quel que
+			// soit l'ordre d'ajout
+			if len(centers) != 1 {
+				t.Errorf("center pixels = %v, want the same sphere in both orders", centers)
+			}
+
+			// Deux sphères identiques ont la même clé : la plus petite identifiant l'emporte
+			scene := Scene{}
+			first := scene.addElement(red)
+			scene.addElement(red)
+			build.build(&scene)
+			if hit := scene.nearestHit(Vec3f{}, Vec3f{0, 0, 1}); hit.id != first {
+				t.Errorf("identical spheres hit object %d, want the first one added (%d)", hit.id, first)
+			}
+		})
+	}
+}
+
+func TestRenderMaskOverlap(t *testing.T) {
+	scene := Scene{}
+	front := scene.addElement(Sphere{1, Vec3f{0, 0, 5}, Lambert{Vec3f{1, 0, 0}}})
+	back := scene.addElement(Sphere{3, Vec3f{0, 0, 10}, Lambert{Vec3f{0, 0, 1}}})
+	camera, _ := NewCamera(Vec3f{}, Vec3f{0, 0, 1}, Vec3f{0, 1, 0}, 40)
+	const width, height = 41, 41
+	frontMask := scene.RenderMask(camera, width, height, front)
+	backMask := scene.RenderMask(camera, width, height, back)
+
+	// La sphère de devant cache le centre de celle de derrière, qui n'en garde qu'un anneau
+	center := (height/2)*width + width/2
+	if !frontMask[center] || backMask[center] {
+		t.Errorf("center pixel: front = %v, back = %v, want only the front sphere", frontMask[center], backMask[center])
+	}
+	var frontCount, backCount int
+	for i := range frontMask {
+		if frontMask[i] && backMask[i] {
+			t.Fatalf("pixel %d is covered by both spheres", i)
+		}
+		if frontMask[i] {
+			frontCount++
+		}
+		if backMask[i] {
+			backCount++
+		}
+	}
+	if frontCount == 0 || backCount == 0 {
+		t.Errorf("front covers %d pixels and back %d, want both visible", frontCount, backCount)
+	}
+}
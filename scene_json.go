@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// jsonVec3 is the JSON representation of a Vec3f, as an array [x, y, z].
+type jsonVec3 [3]float32
+
+func (v jsonVec3) vec() Vec3f {
+	return Vec3f{v[0], v[1], v[2]}
+}
+
+// MaterialFactory builds a material from the parameters of its JSON object, the
+// "type" field included.
+type MaterialFactory func(params map[string]any) (Materials, error)
+
+var (
+	materialsMu       sync.RWMutex
+	materialFactories = make(map[string]MaterialFactory)
+)
+
+// RegisterMaterial makes the material type name available to scene files, built by
+// factory. It panics if the name is already registered or factory is nil, so it is
+// meant to be called from init functions.
+func RegisterMaterial(name string, factory MaterialFactory) {
+	materialsMu.Lock()
+	defer materialsMu.Unlock()
+	if factory == nil {
+		panic("RegisterMaterial: nil factory for material " + name)
+	}
+	if _, dup := materialFactories[name]; dup {
+		panic("RegisterMaterial: material " + name + " registered twice")
+	}
+	materialFactories[name] = factory
+}
+
+// buildMaterial builds the material described by params through the factory
+// registered for its type.
+func buildMaterial(params map[string]any) (Materials, error) {
+	name, _ := params["type"].(string)
+	materialsMu.RLock()
+	factory, ok := materialFactories[name]
+	materialsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown material type %q", name)
+	}
+	return factory(params)
+}
+
+// builtinMaterial returns the factory of a material of this package, decoding its
+// parameters into a jsonMaterial given to build.
+func builtinMaterial(build func(m jsonMaterial) Materials) MaterialFactory {
+	return func(params map[string]any) (Materials, error) {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		var m jsonMaterial
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+		return build(m), nil
+	}
+}
+
+func init() {
+	RegisterMaterial("lambert", builtinMaterial(func(m jsonMaterial) Materials {
+		return Lambert{m.Kd.vec()}
+	}))
+	RegisterMaterial("phong", builtinMaterial(func(m jsonMaterial) Materials {
+		return Phong{m.Ka.vec(), m.Kd.vec(), m.Ks.vec(), m.N}
+	}))
+	RegisterMaterial("mirror", builtinMaterial(func(m jsonMaterial) Materials {
+		return Mirror{m.Kd.vec(), m.Reflectivity}
+	}))
+	RegisterMaterial("dielectric", builtinMaterial(func(m jsonMaterial) Materials {
+		return Dielectric{m.IOR, m.Absorption.vec()}
+	}))
+	RegisterMaterial("emissive", builtinMaterial(func(m jsonMaterial) Materials {
+		return Emissive{m.Color.vec(), m.Intensity}
+	}))
+	RegisterMaterial("checker", builtinMaterial(func(m jsonMaterial) Materials {
+		return Checker{m.Even.vec(), m.Odd.vec(), m.Scale}
+	}))
+	RegisterMaterial("cookTorrance", builtinMaterial(func(m jsonMaterial) Materials {
+		return CookTorrance{m.Albedo.vec(), m.Roughness, m.Metallic}
+	}))
+	RegisterMaterial("glossy", builtinMaterial(func(m jsonMaterial) Materials {
+		return Glossy{m.Color.vec(), m.F0.vec()}
+	}))
+	RegisterMaterial("noise", builtinMaterial(func(m jsonMaterial) Materials {
+		return Noise{m.Low.vec(), m.High.vec(), m.Frequency}
+	}))
+	RegisterMaterial("metal", builtinMaterial(func(m jsonMaterial) Materials {
+		return Metal{m.Albedo.vec(), m.Fuzz}
+	}))
+	RegisterMaterial("mix", mixMaterial)
+}
+
+// mixMaterial builds a Mix from the materials described by its "a" and "b" objects
+// and its "weight".
+func mixMaterial(params map[string]any) (Materials, error) {
+	var sub [2]Materials
+	for i, key := range []string{"a", "b"} {
+		desc, ok := params[key].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("mix: missing material %q", key)
+		}
+		m, err := buildMaterial(desc)
+		if err != nil {
+			return nil, fmt.Errorf("mix %s: %w", key, err)
+		}
+		sub[i] = m
+	}
+	weight, _ := params["weight"].(float64)
+	return Mix{sub[0], sub[1], float32(weight)}, nil
+}
+
+// jsonMaterial describes a material of this package; only the fields used by its type are read.
+type jsonMaterial struct {
+	Type string `json:"type"`
+
+	// lambert, phong, mirror
+	Kd jsonVec3 `json:"kd"`
+	// phong
+	Ka jsonVec3 `json:"ka"`
+	Ks jsonVec3 `json:"ks"`
+	N  float32  `json:"n"`
+	// mirror
+	Reflectivity float32 `json:"reflectivity"`
+	// dielectric
+	IOR        float32  `json:"ior"`
+	Absorption jsonVec3 `json:"absorption"`
+	// emissive, glossy
+	Color     jsonVec3 `json:"color"`
+	Intensity float32  `json:"intensity"`
+	// checker
+	Even  jsonVec3 `json:"even"`
+	Odd   jsonVec3 `json:"odd"`
+	Scale float32  `json:"scale"`
+	// cookTorrance, metal
+	Albedo    jsonVec3 `json:"albedo"`
+	Roughness float32  `json:"roughness"`
+	Metallic  float32  `json:"metallic"`
+	// glossy
+	F0 jsonVec3 `json:"f0"`
+	// noise
+	Low       jsonVec3 `json:"low"`
+	High      jsonVec3 `json:"high"`
+	Frequency float32  `json:"frequency"`
+	// metal
+	Fuzz float32 `json:"fuzz"`
+}
+
+type jsonSphere struct {
+	Radius   float32        `json:"radius"`
+	Position jsonVec3       `json:"position"`
+	Material map[string]any `json:"material"`
+}
+
+type jsonLight struct {
+	Position  jsonVec3 `json:"position"`
+	Color     jsonVec3 `json:"color"`
+	Intensity float32  `json:"intensity"`
+}
+
+type jsonCamera struct {
+	Position      jsonVec3 `json:"position"`
+	At            jsonVec3 `json:"at"`
+	Up            jsonVec3 `json:"up"`
+	Fov           float32  `json:"fov"`
+	Aperture      float32  `json:"aperture"`
+	FocusDistance float32  `json:"focusDistance"`
+	Orthographic  bool     `json:"orthographic"`
+	OrthoScale    float32  `json:"orthoScale"`
+}
+
+// jsonScene is the root of a JSON scene file.
+type jsonScene struct {
+	Camera  jsonCamera   `json:"camera"`
+	Ambient jsonVec3     `json:"ambient"`
+	Spheres []jsonSphere `json:"spheres"`
+	Lights  []jsonLight  `json:"lights"`
+}
+
+// parseScene builds the scene and the camera described by the JSON document data.
+func parseScene(data []byte) (Scene, Camera, error) {
+	var desc jsonScene
+	if err := json.Unmarshal(data, &desc); err != nil {
+		return Scene{}, Camera{}, err
+	}
+
+	scene := Scene{}
+	scene.setAmbient(desc.Ambient.vec())
+	for i, s := range desc.Spheres {
+		m, err := buildMaterial(s.Material)
+		if err != nil {
+			return Scene{}, Camera{}, fmt.Errorf("sphere %d: %w", i, err)
+		}
+		scene.addElement(Sphere{s.Radius, s.Position.vec(), m})
+	}
+	for _, l := range desc.Lights {
+		scene.addLight(Light{color: l.Color.vec(), position: l.Position.vec(), intensity: l.Intensity})
+	}
+
+	camera, err := NewCamera(desc.Camera.Position.vec(), desc.Camera.At.vec(), desc.Camera.Up.vec(), desc.Camera.Fov)
+	if err != nil {
+		return Scene{}, Camera{}, err
+	}
+	camera.aperture = desc.Camera.Aperture
+	camera.focusDistance = desc.Camera.FocusDistance
+	camera.orthographic = desc.Camera.Orthographic
+	camera.orthoScale = desc.Camera.OrthoScale
+	return scene, camera, nil
+}
+
+// LoadScene reads the JSON scene file at path and returns the scene and the
+// camera it describes.
+func LoadScene(path string) (Scene, Camera, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Scene{}, Camera{}, err
+	}
+	scene, camera, err := parseScene(data)
+	if err != nil {
+		return Scene{}, Camera{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return scene, camera, nil
+}
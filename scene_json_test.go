@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseScene(t *testing.T) {
+	const desc = `{
+		"camera": {"position": [0, 0, -5], "at": [0, 0, 0], "up": [0, 1, 0], "fov": 45},
+		"ambient": [0.1, 0.1, 0.1],
+		"spheres": [
+			{"radius": 1.5, "position": [0, 0, 2], "material": {"type": "lambert", "kd": [1, 0, 0]}},
+			{"radius": 0.5, "position": [2, 1, 3], "material": {"type": "phong", "ka": [0.1, 0.1, 0.1], "kd": [0, 1, 0], "ks": [1, 1, 1], "n": 20}}
+		],
+		"lights": [{"position": [0, 10, 0], "color": [1, 1, 1]}]
+	}`
+	scene, camera, err := parseScene([]byte(desc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scene.elements()) != 2 || len(scene.lights) != 1 {
+		t.Fatalf("got %d objects and %d lights, want 2 and 1", len(scene.elements()), len(scene.lights))
+	}
+	s, ok := scene.objects[0].(Sphere)
+	if !ok {
+		t.Fatalf("object 0 is a %T, want a Sphere", scene.objects[0])
+	}
+	if s.radius != 1.5 || s.position != (Vec3f{0, 0, 2}) {
+		t.Errorf("sphere = radius %v at %v, want radius 1.5 at (0, 0, 2)", s.radius, s.position)
+	}
+	if m, ok := s.Material.(Lambert); !ok || m.kd != (Vec3f{1, 0, 0}) {
+		t.Errorf("material = %#v, want a red Lambert", s.Material)
+	}
+	if _, ok := scene.objects[1].(Sphere).Material.(Phong); !ok {
+		t.Errorf("material of object 1 = %T, want Phong", scene.objects[1].(Sphere).Material)
+	}
+	if scene.ambiantLight != (Vec3f{0.1, 0.1, 0.1}) || camera.fovY != 45 {
+		t.Errorf("ambient = %v and fov = %v, want (0.1, 0.1, 0.1) and 45", scene.ambiantLight, camera.fovY)
+	}
+}
+
+// stubMaterial is a material registered by the tests, rendering a flat gray.
+type stubMaterial struct {
+	gray float32
+}
+
+func (m stubMaterial) render(rdi Vec3f, hit Hit, scene Scene, ctx rayContext) Vec3f {
+	return Vec3f{m.gray, m.gray, m.gray}
+}
+
+func init() {
+	RegisterMaterial("stub", func(params map[string]any) (Materials, error) {
+		gray, ok := params["gray"].(float64)
+		if !ok {
+			return nil, errors.New("stub material without gray level")
+		}
+		return stubMaterial{float32(gray)}, nil
+	})
+}
+
+func TestRegisteredMaterial(t *testing.T) {
+	const desc = `{
+		"camera": {"position": [0, 0, -5], "at": [0, 0, 0], "up": [0, 1, 0], "fov": 45},
+		"spheres": [{"radius": 1, "position": [0, 0, 0], "material": {"type": "stub", "gray": 0.25}}]
+	}`
+	scene, _, err := parseScene([]byte(desc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := scene.objects[0].(Sphere).Material; m != (stubMaterial{0.25}) {
+		t.Errorf("material = %#v, want the registered stub with gray 0.25", m)
+	}
+	if got := shade(t, scene, Vec3f{0, 0, -5}, Vec3f{0, 0, 1}); got != (Vec3f{0.25, 0.25, 0.25}) {
+		t.Errorf("color = %v, want the stub gray", got)
+	}
+
+	// Les erreurs de la fabrique remontent avec l'objet concerné
+	bad := strings.Replace(desc, `"gray": 0.25`, `"grey": 0.25`, 1)
+	if _, _, err := parseScene([]byte(bad)); err == nil || !strings.Contains(err.Error(), "sphere 0") {
+		t.Errorf("parseScene with an invalid stub = %v, want an error about sphere 0", err)
+	}
+}
+
+func TestRegisterMaterialTwicePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("registering the stub material twice did not panic")
+		}
+	}()
+	RegisterMaterial("stub", func(map[string]any) (Materials, error) { return nil, nil })
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"io"
+	"math"
+	// Renommé : reflect est la réflexion d'un vecteur dans ce paquet
+	goreflect "reflect"
+)
+
+// tieBreakKey returns the key ordering the objects hit at the same distance: a hash of
+// the type and fields of the object, material included, which does not depend on its
+// identifier. It is computed once by addElement.
+func tieBreakKey(object GeometricObject) uint64 {
+	h := fnv.New64a()
+	hashValue(h, goreflect.ValueOf(object), 0)
+	return h.Sum64()
+}
+
+// maxHashDepth bounds the nesting of the values hashed by hashValue, so that cyclic
+// pointers end, and maxHashElements the number of elements hashed in a slice, so that
+// the pixels of a texture or the parts of a mesh are not all read.
+const (
+	maxHashDepth    = 16
+	maxHashElements = 64
+)
+
+// hashValue writes to h the type and the content of v, following pointers and
+// interfaces. Addresses are never hashed and maps only contribute their length, so
+// that the hash is the same from one run to the next; slices contribute their length
+// and their first maxHashElements elements.
+func hashValue(h hash.Hash64, v goreflect.Value, depth int) {
+	if !v.IsValid() || depth > maxHashDepth {
+		h.Write([]byte{0})
+		return
+	}
+	io.WriteString(h, v.Type().String())
+	var buf [8]byte
+	switch v.Kind() {
+	case goreflect.Bool:
+		if v.Bool() {
+			h.Write([]byte{1})
+		}
+	case goreflect.Int, goreflect.Int8, goreflect.Int16, goreflect.Int32, goreflect.Int64:
+		binary.LittleEndian.PutUint64(buf[:], uint64(v.Int()))
+		h.Write(buf[:])
+	case goreflect.Uint, goreflect.Uint8, goreflect.Uint16, goreflect.Uint32, goreflect.Uint64, goreflect.Uintptr:
+		binary.LittleEndian.PutUint64(buf[:], v.Uint())
+		h.Write(buf[:])
+	case goreflect.Float32, goreflect.Float64:
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v.Float()))
+		h.Write(buf[:])
+	case goreflect.String:
+		io.WriteString(h, v.String())
+	case goreflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			hashValue(h, v.Field(i), depth+1)
+		}
+	case goreflect.Array, goreflect.Slice:
+		binary.LittleEndian.PutUint64(buf[:], uint64(v.Len()))
+		h.Write(buf[:])
+		for i := 0; i < min(v.Len(), maxHashElements); i++ {
+			hashValue(h, v.Index(i), depth+1)
+		}
+	case goreflect.Pointer, goreflect.Interface:
+		hashValue(h, v.Elem(), depth+1)
+	case goreflect.Map:
+		binary.LittleEndian.PutUint64(buf[:], uint64(v.Len()))
+		h.Write(buf[:])
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import "math"
+
+// mat4 is a 4x4 matrix acting on homogeneous coordinates, stored by rows.
+type mat4 [4][4]float32
+
+// identity4 returns the identity matrix.
+func identity4() mat4 {
+	return mat4{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}}
+}
+
+// mul returns the matrix product a × b.
+func (a mat4) mul(b mat4) mat4 {
+	var res mat4
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			for k := 0; k < 4; k++ {
+				res[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return res
+}
+
+// point applies the matrix to the point p, translation included.
+func (a mat4) point(p Vec3f) Vec3f {
+	return Vec3f{
+		a[0][0]*p.x + a[0][1]*p.y + a[0][2]*p.z + a[0][3],
+		a[1][0]*p.x + a[1][1]*p.y + a[1][2]*p.z + a[1][3],
+		a[2][0]*p.x + a[2][1]*p.y + a[2][2]*p.z + a[2][3],
+	}
+}
+
+// vector applies the matrix to the direction v, ignoring the translation.
+func (a mat4) vector(v Vec3f) Vec3f {
+	return Vec3f{
+		a[0][0]*v.x + a[0][1]*v.y + a[0][2]*v.z,
+		a[1][0]*v.x + a[1][1]*v.y + a[1][2]*v.z,
+		a[2][0]*v.x + a[2][1]*v.y + a[2][2]*v.z,
+	}
+}
+
+// transposed returns the transpose of the matrix.
+func (a mat4) transposed() mat4 {
+	var res mat4
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			res[i][j] = a[j][i]
+		}
+	}
+	return res
+}
+
+// Transform is an affine transformation going from the object space of a primitive
+// to the world space. It keeps its inverse along, so that both directions are cheap.
+type Transform struct {
+	m, inv mat4
+}
+
+// identityTransform returns the transformation leaving everything in place.
+func identityTransform() Transform {
+	return Transform{identity4(), identity4()}
+}
+
+// translation returns the transformation moving everything by v.
+func translation(v Vec3f) Transform {
+	m, inv := identity4(), identity4()
+	m[0][3], m[1][3], m[2][3] = v.x, v.y, v.z
+	inv[0][3], inv[1][3], inv[2][3] = -v.x, -v.y, -v.z
+	return Transform{m, inv}
+}
+
+// scaling returns the transformation scaling everything by the factors of v on each
+// axis. The factors must not be null for the transformation to be invertible.
+func scaling(v Vec3f) Transform {
+	m, inv := identity4(), identity4()
+	m[0][0], m[1][1], m[2][2] = v.x, v.y, v.z
+	inv[0][0], inv[1][1], inv[2][2] = 1/v.x, 1/v.y, 1/v.z
+	return Transform{m, inv}
+}
+
+// rotation returns the transformation rotating everything by angle degrees around
+// axis, going through the origin, counter-clockwise when the axis points to the viewer.
+func rotation(axis Vec3f, angle float32) Transform {
+	a := axis.normalized()
+	theta := float64(angle) * math.Pi / 180
+	c, s := float32(math.Cos(theta)), float32(math.Sin(theta))
+	k := 1 - c
+
+	// Formule de Rodrigues ; l'inverse d'une rotation est sa transposée
+	m := mat4{
+		{c + a.x*a.x*k, a.x*a.y*k - a.z*s, a.x*a.z*k + a.y*s, 0},
+		{a.y*a.x*k + a.z*s, c + a.y*a.y*k, a.y*a.z*k - a.x*s, 0},
+		{a.z*a.x*k - a.y*s, a.z*a.y*k + a.x*s, c + a.z*a.z*k, 0},
+		{0, 0, 0, 1},
+	}
+	return Transform{m, m.transposed()}
+}
+
+// then returns the transformation applying t first, then o.
+func (t Transform) then(o Transform) Transform {
+	return Transform{o.m.mul(t.m), t.inv.mul(o.inv)}
+}
+
+// inverse returns the transformation undoing t.
+func (t Transform) inverse() Transform {
+	return Transform{t.inv, t.m}
+}
+
+// point transforms the point p.
+func (t Transform) point(p Vec3f) Vec3f {
+	return t.m.point(p)
+}
+
+// vector transforms the direction v. The result is not normalized.
+func (t Transform) vector(v Vec3f) Vec3f {
+	return t.m.vector(v)
+}
+
+// normal transforms the normal n with the inverse transpose of the matrix, so that
+// it stays orthogonal to the transformed surface under non-uniform scaling.
+func (t Transform) normal(n Vec3f) Vec3f {
+	return t.inv.transposed().vector(n).normalized()
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"fmt"
 	"math"
+	"math/rand"
 )
 
 type Vec2f struct {
@@ -30,36 +32,245 @@ func Mul(v1, v2 Vec3f) Vec3f {
 func Dot(v1, v2 Vec3f) float32 {
 	return v1.x*v2.x + v1.y*v2.y + v1.z*v2.z
 }
+
+// Sub returns the component-wise difference v1 - v2.
 func Sub(v1, v2 Vec3f) Vec3f {
 	return Vec3f{v1.x - v2.x, v1.y - v2.y, v1.z - v2.z}
 }
 
+// Sub returns the component-wise difference v - o.
+func (v Vec3f) Sub(o Vec3f) Vec3f {
+	return Sub(v, o)
+}
+
 func cross(v1, v2 Vec3f) Vec3f {
 	return Vec3f{v1.y*v2.z - v2.y*v1.z, v1.z*v2.x - v2.z*v1.x, v1.x*v2.y - v2.x*v1.y}
 }
 
-func Pow(x, y float32) float32 {
-	return float32(math.Pow(float64(x), float64(y)))
+// Lerp linearly interpolates between a (t = 0) and b (t = 1). Values of t outside
+// [0, 1] extrapolate along the same line.
+func Lerp(a, b Vec3f, t float32) Vec3f {
+	return Add(a.mul(1-t), b.mul(t))
+}
+
+// Lerp linearly interpolates between v (t = 0) and o (t = 1).
+func (v Vec3f) Lerp(o Vec3f, t float32) Vec3f {
+	return Lerp(v, o, t)
+}
+
+// angleBetween returns the angle between a and b in radians, in [0, π]. It uses the
+// arc tangent of sin/cos rather than the arc cosine of the normalized dot product,
+// whose rounding errors give angles of several 1e-4 between identical vectors.
+func angleBetween(a, b Vec3f) float32 {
+	return float32(math.Atan2(float64(cross(a, b).norme()), float64(Dot(a, b))))
+}
+
+// project returns the projection of v on the line of direction onto, which need not
+// be normalized. The projection on a null vector is null.
+func (v Vec3f) project(onto Vec3f) Vec3f {
+	l2 := onto.lengthSquared()
+	if l2 == 0 {
+		return Vec3f{}
+	}
+	return onto.mul(Dot(v, onto) / l2)
+}
+
+// reflect returns the reflection of the incident vector i about the normal n.
+func reflect(i, n Vec3f) Vec3f {
+	return Sub(i, n.mul(2*Dot(i, n)))
+}
+
+// refract returns the direction of the incident vector i refracted through a surface
+// of normal n (facing i) following Snell's law, eta being the ratio of the indices of
+// refraction n1/n2. It returns false on total internal reflection.
+func refract(i, n Vec3f, eta float32) (Vec3f, bool) {
+	cosi := -Dot(i, n)
+	k := 1 - eta*eta*(1-cosi*cosi)
+	if k < 0 {
+		return Vec3f{}, false
+	}
+	return Add(i.mul(eta), n.mul(eta*cosi-float32(math.Sqrt(float64(k))))), true
+}
+
+// fresnelSchlick returns the Schlick approximation of the Fresnel reflectance for a
+// surface of reflectance f0 at normal incidence, cosTheta being the cosine of the
+// angle between the view direction and the normal (or half vector).
+func fresnelSchlick(cosTheta float32, f0 Vec3f) Vec3f {
+	return Add(f0, Sub(Vec3f{1, 1, 1}, f0).mul(Pow(1-cosTheta, 5)))
+}
+
+// Pow returns base**exp. A negative base raised to a fractional exponent has no
+// real result, so it returns 0 instead of NaN.
+func Pow(base, exp float32) float32 {
+	if base < 0 && exp != float32(math.Trunc(float64(exp))) {
+		return 0
+	}
+	return float32(math.Pow(float64(base), float64(exp)))
+}
+
+// buildONB returns two unit vectors orthogonal to the normal n and to each other,
+// completing it into an orthonormal basis (Duff et al., "Building an Orthonormal
+// Basis, Revisited"). It has no singularity, whatever the direction of n.
+func buildONB(n Vec3f) (Vec3f, Vec3f) {
+	n = n.normalized()
+	sign := float32(math.Copysign(1, float64(n.z)))
+	a := -1 / (sign + n.z)
+	b := n.x * n.y * a
+	tangent := Vec3f{1 + sign*n.x*n.x*a, sign * b, -sign * n.x}
+	bitangent := Vec3f{b, sign + n.y*n.y*a, -n.y}
+	return tangent, bitangent
+}
+
+// cosineSampleHemisphere returns a direction of the hemisphere around the normal n,
+// with a density proportional to the cosine of its angle to n.
+func cosineSampleHemisphere(n Vec3f, rng *rand.Rand) Vec3f {
+	tangent, bitangent := buildONB(n)
+	// Point uniforme sur le disque unité, projeté sur l'hémisphère
+	phi := 2 * math.Pi * rng.Float64()
+	r2 := rng.Float32()
+	r := float32(math.Sqrt(float64(r2)))
+	x := r * float32(math.Cos(phi))
+	y := r * float32(math.Sin(phi))
+	z := float32(math.Sqrt(float64(max(1-r2, 0))))
+	return Add(Add(tangent.mul(x), bitangent.mul(y)), n.normalized().mul(z)).normalized()
+}
+
+// randomUnitVector returns a direction uniformly distributed on the unit sphere.
+func randomUnitVector(rng *rand.Rand) Vec3f {
+	for {
+		v := Vec3f{rng.Float32()*2 - 1, rng.Float32()*2 - 1, rng.Float32()*2 - 1}
+		if l := v.lengthSquared(); l > 1e-6 && l <= 1 {
+			return v.normalized()
+		}
+	}
 }
 
 // -------------------------------
 
+// lengthSquared returns the squared length of v. It avoids the square root of
+// norme when only comparing magnitudes.
+func (v Vec3f) lengthSquared() float32 {
+	return v.x*v.x + v.y*v.y + v.z*v.z
+}
+
 func (v Vec3f) norme() float32 {
 	return float32(math.Sqrt(float64(v.x*v.x + v.y*v.y + v.z*v.z)))
 }
+
+// normalizeEpsilon is the length under which a vector is considered null and
+// cannot be normalized.
+const normalizeEpsilon = 1e-12
+
+// normalize scales v to unit length in place. A (nearly) null vector has no
+// direction: it is set to the zero vector instead of NaN components.
 func (v *Vec3f) normalize() {
-	norme := v.norme()
-	v.x /= norme
-	v.y /= norme
-	v.z /= norme
+	*v = v.normalized()
 }
+
+// normalized returns v scaled to unit length, or the zero vector if v is
+// (nearly) null, so that no NaN leaks into the shading.
 func (v Vec3f) normalized() Vec3f {
 	norme := v.norme()
+	if norme < normalizeEpsilon {
+		return Vec3f{}
+	}
 	return Vec3f{v.x / norme, v.y / norme, v.z / norme}
 }
 
+// At returns the component of v along axis (0 for x, 1 for y, 2 for z).
+// It panics if axis is out of range.
+func (v Vec3f) At(axis int) float32 {
+	switch axis {
+	case 0:
+		return v.x
+	case 1:
+		return v.y
+	case 2:
+		return v.z
+	}
+	panic(fmt.Sprintf("Vec3f: axis %d out of range", axis))
+}
+
+// Set sets the component of v along axis (0 for x, 1 for y, 2 for z) to val.
+// It panics if axis is out of range.
+func (v *Vec3f) Set(axis int, val float32) {
+	switch axis {
+	case 0:
+		v.x = val
+	case 1:
+		v.y = val
+	case 2:
+		v.z = val
+	default:
+		panic(fmt.Sprintf("Vec3f: axis %d out of range", axis))
+	}
+}
+
+// maxComponent returns the largest component of v and its axis. Ties go to the lowest axis.
+func (v Vec3f) maxComponent() (float32, int) {
+	value, axis := v.x, 0
+	for i := 1; i < 3; i++ {
+		if v.At(i) > value {
+			value, axis = v.At(i), i
+		}
+	}
+	return value, axis
+}
+
+// minComponent returns the smallest component of v and its axis. Ties go to the lowest axis.
+func (v Vec3f) minComponent() (float32, int) {
+	value, axis := v.x, 0
+	for i := 1; i < 3; i++ {
+		if v.At(i) < value {
+			value, axis = v.At(i), i
+		}
+	}
+	return value, axis
+}
+
 // --------------------------------
 
 type rgbRepresentation struct {
 	r, g, b uint8
 }
+
+// clampColor converts a linear color to its rgbRepresentation, clamping each
+// channel to [0, 1] before scaling it to [0, 255] so that bright values
+// saturate instead of wrapping around. Channels are rounded to the nearest integer.
+func clampColor(v Vec3f) rgbRepresentation {
+	v = clamp01(v)
+	return rgbRepresentation{uint8(v.x*255 + 0.5), uint8(v.y*255 + 0.5), uint8(v.z*255 + 0.5)}
+}
+
+// clamp01 clamps each component of v to [0, 1].
+func clamp01(v Vec3f) Vec3f {
+	clamp := func(c float32) float32 {
+		return min(max(c, 0), 1)
+	}
+	return Vec3f{clamp(v.x), clamp(v.y), clamp(v.z)}
+}
+
+// smoothstep returns 0 below edge0, 1 above edge1 and a smooth Hermite
+// interpolation between them.
+func smoothstep(edge0, edge1, x float32) float32 {
+	if edge0 >= edge1 {
+		if x < edge0 {
+			return 0
+		}
+		return 1
+	}
+	t := min(max((x-edge0)/(edge1-edge0), 0), 1)
+	return t * t * (3 - 2*t)
+}
+
+func abs32(x float32) float32 {
+	return float32(math.Abs(float64(x)))
+}
+
+// sign32 returns -1 for negative values and 1 otherwise.
+func sign32(x float32) float32 {
+	if x < 0 {
+		return -1
+	}
+	return 1
+}
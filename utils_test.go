@@ -0,0 +1,317 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// approx reports whether a and b differ by at most tol.
+func approx(a, b, tol float32) bool {
+	return math.Abs(float64(a-b)) <= float64(tol)
+}
+
+// approxVec reports whether each component of a and b differ by at most tol.
+func approxVec(a, b Vec3f, tol float32) bool {
+	return approx(a.x, b.x, tol) && approx(a.y, b.y, tol) && approx(a.z, b.z, tol)
+}
+
+func TestClampColor(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Vec3f
+		want rgbRepresentation
+	}{
+		{"below 0", Vec3f{-0.5, -1, -100}, rgbRepresentation{0, 0, 0}},
+		{"above 1", Vec3f{1.5, 2, 100}, rgbRepresentation{255, 255, 255}},
+		{"exactly 0", Vec3f{0, 0, 0}, rgbRepresentation{0, 0, 0}},
+		{"exactly 1", Vec3f{1, 1, 1}, rgbRepresentation{255, 255, 255}},
+		{"mixed", Vec3f{-1, 0.5, 3}, rgbRepresentation{0, 128, 255}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clampColor(tt.in); got != tt.want {
+				t.Errorf("clampColor(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefractBendsTowardNormal(t *testing.T) {
+	n := Vec3f{0, 1, 0}
+	// Rayon incident à 45° passant de l'air (1) au verre (1.5)
+	i := Vec3f{1, -1, 0}.normalized()
+	rd, ok := refract(i, n, 1/1.5)
+	if !ok {
+		t.Fatal("refract reports total internal reflection entering a denser medium")
+	}
+	incidence := angleBetween(i, n.inverte())
+	refraction := angleBetween(rd, n.inverte())
+	if refraction >= incidence {
+		t.Errorf("refraction angle %v, want less than the incidence angle %v", refraction, incidence)
+	}
+	// Loi de Snell : n1 sin θ1 = n2 sin θ2
+	if s1, s2 := float32(math.Sin(float64(incidence))), 1.5*float32(math.Sin(float64(refraction))); !approx(s1, s2, 1e-5) {
+		t.Errorf("n1 sin θ1 = %v, n2 sin θ2 = %v", s1, s2)
+	}
+}
+
+func TestRefractTotalInternalReflection(t *testing.T) {
+	n := Vec3f{0, 1, 0}
+	// Du verre vers l'air, l'angle critique vaut asin(1/1.5) ≈ 41.8°
+	critical := math.Asin(1 / 1.5)
+	for _, tt := range []struct {
+		angle float64
+		tir   bool
+	}{
+		{critical - 0.05, false},
+		{critical + 0.05, true},
+		{math.Pi / 3, true},
+	} {
+		i := Vec3f{float32(math.Sin(tt.angle)), -float32(math.Cos(tt.angle)), 0}
+		if _, ok := refract(i, n, 1.5); ok == tt.tir {
+			t.Errorf("angle %.3f rad: total internal reflection = %v, want %v", tt.angle, !ok, tt.tir)
+		}
+	}
+}
+
+func TestSub(t *testing.T) {
+	tests := []struct {
+		a, b, want Vec3f
+	}{
+		{Vec3f{3, 5, 7}, Vec3f{1, 2, 3}, Vec3f{2, 3, 4}},
+		{Vec3f{1, 2, 3}, Vec3f{3, 5, 7}, Vec3f{-2, -3, -4}},
+		{Vec3f{1, -1, 0.5}, Vec3f{}, Vec3f{1, -1, 0.5}},
+		{Vec3f{4, 4, 4}, Vec3f{4, 4, 4}, Vec3f{}},
+	}
+	for _, tt := range tests {
+		if got := Sub(tt.a, tt.b); got != tt.want {
+			t.Errorf("Sub(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := tt.a.Sub(tt.b); got != tt.want {
+			t.Errorf("%v.Sub(%v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestPow(t *testing.T) {
+	tests := []struct {
+		name      string
+		base, exp float32
+		want      float32
+	}{
+		{"2^3", 2, 3, 8},
+		{"0^10", 0, 10, 0},
+		{"0^0.5", 0, 0.5, 0},
+		{"0^50", 0, 50, 0},
+		{"negative base, fractional exponent", -0.5, 2.5, 0},
+		{"negative base, integer exponent", -2, 3, -8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Pow(tt.base, tt.exp)
+			if math.IsNaN(float64(got)) || got != tt.want {
+				t.Errorf("Pow(%v, %v) = %v, want %v", tt.base, tt.exp, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLengthSquared(t *testing.T) {
+	for _, v := range []Vec3f{{}, {1, 0, 0}, {3, 4, 0}, {-1, 2, -3}, {0.1, 0.2, 0.3}} {
+		n := v.norme()
+		if got := v.lengthSquared(); !approx(got, n*n, 1e-5*max(got, 1)) {
+			t.Errorf("%v.lengthSquared() = %v, want norme² = %v", v, got, n*n)
+		}
+	}
+}
+
+var benchmarkLength float32
+
+func BenchmarkLengthSquared(b *testing.B) {
+	v := Vec3f{1, 2, 3}
+	for i := 0; i < b.N; i++ {
+		benchmarkLength = v.lengthSquared()
+	}
+}
+
+func BenchmarkNorme(b *testing.B) {
+	v := Vec3f{1, 2, 3}
+	for i := 0; i < b.N; i++ {
+		benchmarkLength = v.norme()
+	}
+}
+
+func TestLerp(t *testing.T) {
+	a, b := Vec3f{0, 2, -4}, Vec3f{10, 4, 4}
+	tests := []struct {
+		t    float32
+		want Vec3f
+	}{
+		{0, a},
+		{1, b},
+		{0.5, Vec3f{5, 3, 0}},
+		{2, Vec3f{20, 6, 12}},
+		{-1, Vec3f{-10, 0, -12}},
+	}
+	for _, tt := range tests {
+		if got := Lerp(a, b, tt.t); !approxVec(got, tt.want, 1e-6) {
+			t.Errorf("Lerp(%v, %v, %v) = %v, want %v", a, b, tt.t, got, tt.want)
+		}
+		if got := a.Lerp(b, tt.t); !approxVec(got, tt.want, 1e-6) {
+			t.Errorf("%v.Lerp(%v, %v) = %v, want %v", a, b, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeZeroVector(t *testing.T) {
+	for _, v := range []Vec3f{{}, {1e-20, 0, -1e-20}} {
+		got := v.normalized()
+		w := v
+		w.normalize()
+		for _, r := range []Vec3f{got, w} {
+			if math.IsNaN(float64(r.x)) || math.IsNaN(float64(r.y)) || math.IsNaN(float64(r.z)) {
+				t.Errorf("normalizing %v gives %v, want no NaN", v, r)
+			}
+			if r != (Vec3f{}) {
+				t.Errorf("normalizing %v gives %v, want the zero vector", v, r)
+			}
+		}
+	}
+	if got := (Vec3f{0, 3, 4}).normalized(); !approxVec(got, Vec3f{0, 0.6, 0.8}, 1e-6) {
+		t.Errorf("(0, 3, 4).normalized() = %v, want (0, 0.6, 0.8)", got)
+	}
+}
+
+func TestAtSet(t *testing.T) {
+	v := Vec3f{1, 2, 3}
+	for axis, want := range []float32{1, 2, 3} {
+		if got := v.At(axis); got != want {
+			t.Errorf("At(%d) = %v, want %v", axis, got, want)
+		}
+	}
+	for axis := 0; axis < 3; axis++ {
+		w := v
+		w.Set(axis, -1)
+		for other := 0; other < 3; other++ {
+			want := v.At(other)
+			if other == axis {
+				want = -1
+			}
+			if got := w.At(other); got != want {
+				t.Errorf("after Set(%d, -1), At(%d) = %v, want %v", axis, other, got, want)
+			}
+		}
+	}
+}
+
+func TestAtSetOutOfRange(t *testing.T) {
+	for _, axis := range []int{-1, 3} {
+		t.Run("At", func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("At(%d) did not panic", axis)
+				}
+			}()
+			Vec3f{}.At(axis)
+		})
+		t.Run("Set", func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Set(%d) did not panic", axis)
+				}
+			}()
+			var v Vec3f
+			v.Set(axis, 1)
+		})
+	}
+}
+
+func TestMinMaxComponent(t *testing.T) {
+	v := Vec3f{2, -1, 5}
+	if value, axis := v.maxComponent(); value != 5 || axis != 2 {
+		t.Errorf("maxComponent() = %v, %d, want 5, 2", value, axis)
+	}
+	if value, axis := v.minComponent(); value != -1 || axis != 1 {
+		t.Errorf("minComponent() = %v, %d, want -1, 1", value, axis)
+	}
+}
+
+func TestReflectHorizontalNormal(t *testing.T) {
+	// Rayon descendant à 45° sur un sol horizontal : il repart vers le haut
+	i := Vec3f{1, -1, 0}.normalized()
+	got := reflect(i, Vec3f{0, 1, 0})
+	if want := (Vec3f{1, 1, 0}).normalized(); !approxVec(got, want, 1e-6) {
+		t.Errorf("reflect(%v) = %v, want %v", i, got, want)
+	}
+}
+
+func TestFresnelSchlick(t *testing.T) {
+	f0 := Vec3f{0.04, 0.04, 0.04}
+	if got := fresnelSchlick(1, f0); !approxVec(got, f0, 1e-6) {
+		t.Errorf("reflectance at normal incidence = %v, want f0 = %v", got, f0)
+	}
+	if got := fresnelSchlick(0, f0); !approxVec(got, Vec3f{1, 1, 1}, 1e-6) {
+		t.Errorf("reflectance at grazing incidence = %v, want 1", got)
+	}
+}
+
+func TestBuildONB(t *testing.T) {
+	for _, n := range []Vec3f{
+		{0, 0, 1}, {0, 0, -1}, {1, 0, 0}, {0, 1, 0},
+		Vec3f{1, 2, 3}.normalized(), Vec3f{-0.3, 0.1, -0.9}.normalized(),
+	} {
+		tangent, bitangent := buildONB(n)
+		for _, v := range []Vec3f{tangent, bitangent} {
+			if !approx(v.norme(), 1, 1e-5) {
+				t.Errorf("n = %v: %v has length %v, want 1", n, v, v.norme())
+			}
+		}
+		for _, d := range []float32{Dot(tangent, bitangent), Dot(tangent, n), Dot(bitangent, n)} {
+			if !approx(d, 0, 1e-5) {
+				t.Errorf("n = %v: basis (%v, %v) has a dot product of %v, want orthogonal", n, tangent, bitangent, d)
+			}
+		}
+	}
+}
+
+func TestCosineSampleHemisphere(t *testing.T) {
+	n := Vec3f{1, -2, 0.5}.normalized()
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		d := cosineSampleHemisphere(n, rng)
+		if !approx(d.norme(), 1, 1e-5) {
+			t.Fatalf("direction %v has length %v, want 1", d, d.norme())
+		}
+		if Dot(d, n) < 0 {
+			t.Fatalf("direction %v is below the surface of normal %v", d, n)
+		}
+	}
+}
+
+func TestAngleBetween(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		a, b Vec3f
+		want float32
+	}{
+		{"orthogonal", Vec3f{1, 0, 0}, Vec3f{0, 3, 0}, math.Pi / 2},
+		{"identical", Vec3f{1, 2, 3}, Vec3f{1, 2, 3}, 0},
+		{"opposite", Vec3f{0, 0, 1}, Vec3f{0, 0, -2}, math.Pi},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := angleBetween(tt.a, tt.b); !approx(got, tt.want, 1e-6) {
+				t.Errorf("angleBetween(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProject(t *testing.T) {
+	if got := (Vec3f{1, 1, 0}).project(Vec3f{2, 0, 0}); got != (Vec3f{1, 0, 0}) {
+		t.Errorf("projection of (1, 1, 0) on x = %v, want (1, 0, 0)", got)
+	}
+	if got := (Vec3f{1, 1, 0}).project(Vec3f{}); got != (Vec3f{}) {
+		t.Errorf("projection on the null vector = %v, want null", got)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Validate returns an error describing the first problem of the scene that would
+// make it render wrongly: an object without material or with a non-positive size,
+// or objects needing lights in a scene lit neither by a light, nor by an environment
+// map or an ambient light.
+func (s Scene) Validate() error {
+	needsLight := false
+	for id, object := range s.objects {
+		if object == nil {
+			continue
+		}
+		m, err := validateObject(object)
+		if err == nil {
+			err = validateMaterial(m)
+		}
+		if err != nil {
+			return fmt.Errorf("object %d: %v", id, err)
+		}
+		needsLight = needsLight || materialNeedsLight(m)
+	}
+	if needsLight && !s.hasLighting() {
+		return errors.New("the scene has no light: add one with addLight, an environment or an ambient light, or only use emissive, dielectric and metal materials")
+	}
+	if s.aoSamples > 0 && s.aoRadius <= 0 {
+		return fmt.Errorf("ambient occlusion radius %g is not positive", s.aoRadius)
+	}
+	return nil
+}
+
+// hasLighting reports whether something lights the objects of the scene: a light, the
+// environment map or the ambient light.
+func (s Scene) hasLighting() bool {
+	return len(s.lights) > 0 || s.environment != nil || s.ambiantLight != (Vec3f{})
+}
+
+// validateMaterial checks that the materials blended by m, if any, are set.
+func validateMaterial(m Materials) error {
+	if mix, ok := m.(Mix); ok {
+		if mix.a == nil || mix.b == nil {
+			return errors.New("mix of a missing material")
+		}
+		if err := validateMaterial(mix.a); err != nil {
+			return err
+		}
+		return validateMaterial(mix.b)
+	}
+	return nil
+}
+
+// materialNeedsLight reports whether the material m is lit by the lights of the scene.
+func materialNeedsLight(m Materials) bool {
+	switch m := m.(type) {
+	case Emissive, Dielectric, Metal:
+		// Ces matériaux ne dépendent pas des lumières de la scène
+		return false
+	case Mix:
+		return materialNeedsLight(m.a) || materialNeedsLight(m.b)
+	}
+	return true
+}
+
+// validateObject checks the dimensions of the primitive object and returns its
+// material. The material is nil for objects it does not know.
+func validateObject(object GeometricObject) (Materials, error) {
+	var m Materials
+	switch o := object.(type) {
+	case Sphere:
+		if o.radius <= 0 {
+			return nil, fmt.Errorf("sphere radius %g is not positive", o.radius)
+		}
+		m = o.Material
+	case Cylinder:
+		if o.radius <= 0 || o.height <= 0 {
+			return nil, fmt.Errorf("cylinder radius %g and height %g must be positive", o.radius, o.height)
+		}
+		m = o.Material
+	case Disk:
+		if o.radius <= 0 {
+			return nil, fmt.Errorf("disk radius %g is not positive", o.radius)
+		}
+		m = o.Material
+	case Torus:
+		if o.majorRadius <= 0 || o.minorRadius <= 0 {
+			return nil, fmt.Errorf("torus radii %g and %g must be positive", o.majorRadius, o.minorRadius)
+		}
+		m = o.Material
+	case Plane:
+		m = o.Material
+	case Quad:
+		if cross(o.u, o.v).lengthSquared() == 0 {
+			return nil, errors.New("quad edges are null or parallel")
+		}
+		m = o.Material
+	case Triangle:
+		m = o.Material
+	case AABB:
+		m = o.Material
+	case Box:
+		return validateObject(o.extent)
+	case Transformed:
+		return validateObject(o.object)
+	case Mesh:
+		if len(o.parts) == 0 {
+			return nil, errors.New("mesh has no part")
+		}
+		// Le maillage a besoin d'une lumière dès que l'une de ses parties en a besoin
+		for i, part := range o.parts {
+			pm, err := validateObject(part)
+			if err == nil {
+				err = validateMaterial(pm)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("part %d: %v", i, err)
+			}
+			if m == nil || (pm != nil && materialNeedsLight(pm)) {
+				m = pm
+			}
+		}
+		return m, nil
+	default:
+		return nil, nil
+	}
+	if m == nil {
+		return nil, errors.New("no material")
+	}
+	return m, nil
+}
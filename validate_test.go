@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateNoLight(t *testing.T) {
+	scene := Scene{}
+	scene.addElement(Sphere{1, Vec3f{0, 0, 5}, Lambert{Vec3f{1, 0, 0}}})
+	err := scene.Validate()
+	if err == nil || !strings.Contains(err.Error(), "no light") {
+		t.Fatalf("Validate() = %v, want an error about the missing light", err)
+	}
+	camera, _ := NewCamera(Vec3f{}, Vec3f{0, 0, 1}, Vec3f{0, 1, 0}, 40)
+	if _, _, err := scene.Render(camera, 4, 4, RenderOptions{}); err == nil {
+		t.Error("Render of a scene without light succeeded, want an error")
+	}
+
+	// Un matériau émissif n'a pas besoin de lumière
+	emissive := Scene{}
+	emissive.addElement(Sphere{1, Vec3f{0, 0, 5}, Emissive{Vec3f{1, 0, 0}, 1}})
+	if err := emissive.Validate(); err != nil {
+		t.Errorf("Validate() of an emissive scene = %v, want nil", err)
+	}
+
+	// L'environnement et la lumière ambiante éclairent aussi la scène
+	withEnvironment := scene
+	withEnvironment.environment = &Environment{}
+	if err := withEnvironment.Validate(); err != nil {
+		t.Errorf("Validate() of a scene lit by its environment = %v, want nil", err)
+	}
+	withAmbient := scene
+	withAmbient.setAmbient(Vec3f{0.2, 0.2, 0.2})
+	if err := withAmbient.Validate(); err != nil {
+		t.Errorf("Validate() of a scene lit by an ambient light = %v, want nil", err)
+	}
+}
+
+func TestValidateMesh(t *testing.T) {
+	scene := Scene{}
+	scene.addElement(NewMesh([]GeometricObject{
+		Triangle{v0: Vec3f{0, 0, 0}, v1: Vec3f{1, 0, 0}, v2: Vec3f{0, 1, 0}, Material: Emissive{Vec3f{1, 1, 1}, 1}},
+		Triangle{v0: Vec3f{0, 0, 0}, v1: Vec3f{0, 1, 0}, v2: Vec3f{-1, 0, 0}},
+	}))
+	if err := scene.Validate(); err == nil || !strings.Contains(err.Error(), "part 1") {
+		t.Errorf("Validate() = %v, want an error about the part 1 without material", err)
+	}
+
+	// La partie diffuse demande une lumière, même si la première est émissive
+	scene = Scene{}
+	scene.addElement(NewMesh([]GeometricObject{
+		Triangle{v0: Vec3f{0, 0, 0}, v1: Vec3f{1, 0, 0}, v2: Vec3f{0, 1, 0}, Material: Emissive{Vec3f{1, 1, 1}, 1}},
+		Triangle{v0: Vec3f{0, 0, 0}, v1: Vec3f{0, 1, 0}, v2: Vec3f{-1, 0, 0}, Material: Lambert{Vec3f{1, 1, 1}}},
+	}))
+	if err := scene.Validate(); err == nil || !strings.Contains(err.Error(), "no light") {
+		t.Errorf("Validate() = %v, want an error about the missing light", err)
+	}
+}
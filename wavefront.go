@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LoadOBJ reads a Wavefront OBJ file and returns its faces as double-sided triangles
+// sharing the material m, ready to be added to a Scene one by one or grouped by NewMesh.
+// Only vertices (v), vertex normals (vn) and faces (f) are supported; polygons are
+// triangulated as a fan around their first vertex, and faces giving a normal for
+// each of their vertices are smooth shaded. Comments and other directives are ignored.
+func LoadOBJ(path string, m Materials) ([]GeometricObject, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var vertices, normals []Vec3f
+	var objects []GeometricObject
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		switch fields[0] {
+		case "v", "vn":
+			name := "vertex"
+			if fields[0] == "vn" {
+				name = "vertex normal"
+			}
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("%s:%d: %s needs 3 coordinates", path, lineNumber, name)
+			}
+			var coords [3]float32
+			for i := range coords {
+				f, err := strconv.ParseFloat(fields[i+1], 32)
+				if err != nil {
+					return nil, fmt.Errorf("%s:%d: invalid %s coordinate %q", path, lineNumber, name, fields[i+1])
+				}
+				coords[i] = float32(f)
+			}
+			if fields[0] == "v" {
+				vertices = append(vertices, Vec3f{coords[0], coords[1], coords[2]})
+			} else {
+				normals = append(normals, Vec3f{coords[0], coords[1], coords[2]})
+			}
+		case "f":
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("%s:%d: face needs at least 3 vertices", path, lineNumber)
+			}
+			face := make([]Vec3f, 0, len(fields)-1)
+			faceNormals := make([]Vec3f, 0, len(fields)-1)
+			for _, field := range fields[1:] {
+				// Les sommets peuvent être de la forme v, v/vt, v//vn ou v/vt/vn
+				parts := strings.Split(field, "/")
+				idx, err := objIndex(parts[0], len(vertices))
+				if err != nil {
+					return nil, fmt.Errorf("%s:%d: face vertex %q: %v", path, lineNumber, field, err)
+				}
+				face = append(face, vertices[idx])
+
+				if len(parts) < 3 || parts[2] == "" {
+					continue
+				}
+				idx, err = objIndex(parts[2], len(normals))
+				if err != nil {
+					return nil, fmt.Errorf("%s:%d: face vertex normal %q: %v", path, lineNumber, field, err)
+				}
+				faceNormals = append(faceNormals, normals[idx])
+			}
+			for i := 1; i < len(face)-1; i++ {
+				// L'orientation des faces d'un fichier OBJ n'est pas fiable : elles restent
+				// éclairées des deux côtés
+				triangle := Triangle{v0: face[0], v1: face[i], v2: face[i+1], Material: m}
+				// Normales aux sommets seulement si chaque sommet de la face en a une
+				if len(faceNormals) == len(face) {
+					triangle.n0, triangle.n1, triangle.n2 = faceNormals[0], faceNormals[i], faceNormals[i+1]
+				}
+				objects = append(objects, triangle)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// objIndex converts the 1-based index s of an OBJ face into an index of a list of
+// count elements. Negative indices are relative to the end of the list.
+func objIndex(s string, count int) (int, error) {
+	idx, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid index %q", s)
+	}
+	if idx < 0 {
+		idx = count + idx + 1
+	}
+	if idx < 1 || idx > count {
+		return 0, fmt.Errorf("index %d out of range", idx)
+	}
+	return idx - 1, nil
+}
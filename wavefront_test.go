@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOBJQuad(t *testing.T) {
+	const quad = `# un carré unité
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+f 1 2 3 4
+`
+	path := filepath.Join(t.TempDir(), "quad.obj")
+	if err := os.WriteFile(path, []byte(quad), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	objects, err := LoadOBJ(path, Lambert{Vec3f{1, 1, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 {
+		t.Fatalf("got %d objects, want 2", len(objects))
+	}
+
+	// Éventail autour du premier sommet : (1, 2, 3) puis (1, 3, 4)
+	want := [2][3]Vec3f{
+		{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}},
+		{{0, 0, 0}, {1, 1, 0}, {0, 1, 0}},
+	}
+	for i, object := range objects {
+		tr, ok := object.(Triangle)
+		if !ok {
+			t.Fatalf("object %d is a %T, want a Triangle", i, object)
+		}
+		if got := [3]Vec3f{tr.v0, tr.v1, tr.v2}; got != want[i] {
+			t.Errorf("triangle %d vertices = %v, want %v", i, got, want[i])
+		}
+	}
+}